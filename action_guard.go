@@ -0,0 +1,64 @@
+package funnelfox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrActionNotAvailable 订阅当前不允许执行请求的操作
+type ErrActionNotAvailable struct {
+	SubsID  string
+	Action  SubscriptionAction
+	Allowed []SubscriptionAction // 订阅当前允许的操作
+}
+
+func (e *ErrActionNotAvailable) Error() string {
+	allowed := make([]string, 0, len(e.Allowed))
+	for _, a := range e.Allowed {
+		allowed = append(allowed, string(a))
+	}
+	return fmt.Sprintf("action %s not available for subscription %s (available: [%s])",
+		e.Action, e.SubsID, strings.Join(allowed, ", "))
+}
+
+// SetActionGuard 开启后，修改订阅前先通过 GetMyAssets 检查 AvailableActions，
+// 不允许时返回包装了 *ErrActionNotAvailable 的错误，不再发送请求；
+// 订阅的状态或操作中有 SDK 未定义的值时不拦截，照常发送请求
+func (c *Client) SetActionGuard(enabled bool) {
+	c.actionGuard = enabled
+}
+
+// checkAction 在开启 action guard 时检查订阅是否允许执行 action
+func (c *Client) checkAction(externalID, subsID string, action SubscriptionAction) *Error {
+	if !c.actionGuard {
+		return nil
+	}
+	assets, err := c.GetMyAssets(MyAssetsRequest{ExternalID: externalID})
+	if err != nil {
+		return WrapError(err, "action guard: failed to get subscription")
+	}
+	for _, sub := range assets.Subscriptions {
+		if sub.SubsID != subsID {
+			continue
+		}
+		if sub.Can(action) {
+			return nil
+		}
+		// 状态和操作取值不是 API 文档给出的完整枚举，出现 SDK 未定义的值时无法可靠判断，交给 API 决定
+		if len(sub.UnknownStatuses()) > 0 || len(sub.UnknownActions()) > 0 {
+			c.logger.Info("funnelfox_action_guard_skipped",
+				String("subs_id", subsID),
+				String("action", string(action)))
+			return nil
+		}
+		c.logger.Info("funnelfox_action_not_available",
+			String("subs_id", subsID),
+			String("action", string(action)))
+		return WrapError(&ErrActionNotAvailable{
+			SubsID:  subsID,
+			Action:  action,
+			Allowed: sub.AvailableActions,
+		}, "action guard")
+	}
+	return WrapErrorf(nil, "action guard: subscription %s not found for external_id %s", subsID, externalID)
+}
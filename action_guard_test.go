@@ -0,0 +1,152 @@
+package funnelfox
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestActionGuard(t *testing.T) {
+	const assets = `{"status":"success","data":{"subscriptions":[
+		{"subs_id":"s1","status":["active"],"available_actions":["pause","defer"]}
+	]}}`
+	tests := []struct {
+		name          string
+		guard         bool
+		subsID        string
+		assetsStatus  int
+		wantEndpoints []string
+		wantErr       string
+		wantNotAvail  bool
+	}{
+		{
+			name:          "disabled",
+			subsID:        "s1",
+			wantEndpoints: []string{"/subscription/resume"},
+		},
+		{
+			name:          "not available",
+			guard:         true,
+			subsID:        "s1",
+			assetsStatus:  http.StatusOK,
+			wantEndpoints: []string{"/my_assets"},
+			wantErr:       "action resume not available for subscription s1 (available: [pause, defer])",
+			wantNotAvail:  true,
+		},
+		{
+			name:          "subscription not found",
+			guard:         true,
+			subsID:        "s2",
+			assetsStatus:  http.StatusOK,
+			wantEndpoints: []string{"/my_assets"},
+			wantErr:       "subscription s2 not found",
+		},
+		{
+			name:          "assets error",
+			guard:         true,
+			subsID:        "s1",
+			assetsStatus:  http.StatusInternalServerError,
+			wantEndpoints: []string{"/my_assets"},
+			wantErr:       "action guard: failed to get subscription",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var endpoints []string
+			c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				endpoint := strings.TrimPrefix(req.URL.Path, "/org/v1")
+				endpoints = append(endpoints, endpoint)
+				if strings.Contains(endpoint, "assets") {
+					return jsonResponse(tt.assetsStatus, assets), nil
+				}
+				return jsonResponse(http.StatusOK, okResponse), nil
+			})
+			c.SetActionGuard(tt.guard)
+			err := c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: tt.subsID})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			var notAvail *ErrActionNotAvailable
+			if err != nil && errors.As(err, &notAvail) != tt.wantNotAvail {
+				t.Errorf("errors.As(*ErrActionNotAvailable) = %v", !tt.wantNotAvail)
+			}
+			assertStrings(t, "endpoints", endpoints, tt.wantEndpoints)
+		})
+	}
+}
+
+func TestActionGuardAllows(t *testing.T) {
+	var endpoints []string
+	c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		endpoint := strings.TrimPrefix(req.URL.Path, "/org/v1")
+		endpoints = append(endpoints, endpoint)
+		if strings.Contains(endpoint, "assets") {
+			return jsonResponse(http.StatusOK, `{"status":"success","data":{"subscriptions":[
+				{"subs_id":"s1","available_actions":["resume"]}]}}`), nil
+		}
+		return jsonResponse(http.StatusOK, okResponse), nil
+	})
+	c.SetActionGuard(true)
+	if err := c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 2 || endpoints[1] != "/subscription/resume" {
+		t.Errorf("endpoints = %v", endpoints)
+	}
+}
+
+func TestActionGuardUnknownValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		sub           string
+		wantEndpoints []string
+		wantErr       bool
+	}{
+		{
+			name:          "known values deny",
+			sub:           `{"subs_id":"s1","status":["paused"],"available_actions":["defer"]}`,
+			wantEndpoints: []string{"/my_assets"},
+			wantErr:       true,
+		},
+		{
+			name:          "unknown status allows",
+			sub:           `{"subs_id":"s1","status":["frozen"],"available_actions":["defer"]}`,
+			wantEndpoints: []string{"/my_assets", "/subscription/resume"},
+		},
+		{
+			name:          "unknown action allows",
+			sub:           `{"subs_id":"s1","status":["paused"],"available_actions":["unpause"]}`,
+			wantEndpoints: []string{"/my_assets", "/subscription/resume"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			var endpoints []string
+			c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				endpoint := strings.TrimPrefix(req.URL.Path, "/org/v1")
+				endpoints = append(endpoints, endpoint)
+				if strings.Contains(endpoint, "assets") {
+					return jsonResponse(http.StatusOK, `{"status":"success","data":{"subscriptions":[`+tt.sub+`]}}`), nil
+				}
+				return jsonResponse(http.StatusOK, okResponse), nil
+			})
+			c.logger = logger
+			c.SetActionGuard(true)
+			err := c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			assertStrings(t, "endpoints", endpoints, tt.wantEndpoints)
+			if skipped := slices.Contains(logger.messages(), "funnelfox_action_guard_skipped"); skipped == tt.wantErr {
+				t.Errorf("guard skipped = %v", skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package analytics
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+)
+
+// amountDecimals JSON 和 String 输出的最大小数位数
+const amountDecimals = 6
+
+// Amount 精确金额，内部使用 big.Rat 累加，避免浮点误差
+//
+// 零值表示 0；JSON 输出为十进制字符串，最多保留 6 位小数
+type Amount struct {
+	r *big.Rat
+}
+
+// Rat 返回金额的副本
+func (a Amount) Rat() *big.Rat {
+	if a.r == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Set(a.r)
+}
+
+// Float64 返回最接近的 float64，仅用于展示或图表
+func (a Amount) Float64() float64 {
+	f, _ := a.Rat().Float64()
+	return f
+}
+
+// String 返回十进制字符串，去掉末尾的 0
+func (a Amount) String() string {
+	s := a.Rat().FloatString(amountDecimals)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// add 累加 x，每次生成新的 big.Rat，复制出的 Amount 互不影响
+func (a *Amount) add(x *big.Rat) {
+	a.r = new(big.Rat).Add(a.Rat(), x)
+}
+
+func amountOf(x *big.Rat) Amount {
+	return Amount{r: new(big.Rat).Set(x)}
+}
@@ -0,0 +1,365 @@
+package analytics
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// Dimension 交易的分组维度
+type Dimension string
+
+const (
+	DimensionAll            Dimension = "all" // 不分组
+	DimensionPSP            Dimension = "psp"
+	DimensionIssuerCountry  Dimension = "issuer_country"
+	DimensionNetwork        Dimension = "network"
+	DimensionFundingType    Dimension = "funding_type"
+	DimensionCIT            Dimension = "is_cit"
+	DimensionFallback       Dimension = "is_fallback"
+	DimensionRetryStep      Dimension = "retry_step"
+	DimensionThreeDSVersion Dimension = "threeds_version"
+)
+
+// DeclineDimensions 拒付报告默认的分组维度
+var DeclineDimensions = []Dimension{
+	DimensionPSP,
+	DimensionIssuerCountry,
+	DimensionNetwork,
+	DimensionFundingType,
+	DimensionCIT,
+	DimensionFallback,
+	DimensionRetryStep,
+}
+
+// unknownValue 字段缺失时的分组值
+const unknownValue = "unknown"
+
+// dimensionValue 返回交易在维度 d 上的取值
+func dimensionValue(t *funnelfox.Transaction, d Dimension) string {
+	switch d {
+	case DimensionAll:
+		return string(DimensionAll)
+	case DimensionPSP:
+		return orUnknown(&t.PSP)
+	case DimensionIssuerCountry:
+		return orUnknown(t.PMDataBinIssuerCountryCode)
+	case DimensionNetwork:
+		return orUnknown(t.Network)
+	case DimensionFundingType:
+		return orUnknown(t.PMDataBinAccountFundingType)
+	case DimensionCIT:
+		return strconv.FormatBool(t.IsCIT)
+	case DimensionFallback:
+		return strconv.FormatBool(t.IsFallback)
+	case DimensionRetryStep:
+		return orUnknown(t.MetaRetryStep)
+	case DimensionThreeDSVersion:
+		return orUnknown(t.ThreeDSProtocolVersion)
+	}
+	return unknownValue
+}
+
+func orUnknown(s *string) string {
+	if s == nil || *s == "" {
+		return unknownValue
+	}
+	return *s
+}
+
+// DeclineReason 拒付原因
+type DeclineReason struct {
+	Type        string `json:"type"`         // PSPReasonType
+	Code        string `json:"code"`         // PSPReasonCode
+	DeclineType string `json:"decline_type"` // PSPReasonDeclineType，如 soft/hard
+}
+
+func declineReason(t *funnelfox.Transaction) DeclineReason {
+	return DeclineReason{
+		Type:        orUnknown(t.PSPReasonType),
+		Code:        orUnknown(t.PSPReasonCode),
+		DeclineType: orUnknown(t.PSPReasonDeclineType),
+	}
+}
+
+// ReasonCount 拒付原因及次数
+type ReasonCount struct {
+	DeclineReason
+	Count   int    `json:"count"`
+	Message string `json:"message,omitempty"` // 出现次数最多的 PSPReasonMessage
+}
+
+// ApprovalStats 通过率统计
+type ApprovalStats struct {
+	Value        string        `json:"value"`
+	Attempts     int           `json:"attempts"` // 成功和失败交易数量
+	Approved     int           `json:"approved"`
+	Declined     int           `json:"declined"`
+	ApprovalRate float64       `json:"approval_rate"`
+	TopReasons   []ReasonCount `json:"top_reasons"`
+}
+
+// DeclineRate 失败率
+func (s *ApprovalStats) DeclineRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Declined) / float64(s.Attempts)
+}
+
+// DeclineReport 拒付报告
+type DeclineReport struct {
+	Total ApprovalStats `json:"total"`
+	// Slices 各维度的分组统计，按交易数量降序
+	Slices map[Dimension][]ApprovalStats `json:"slices"`
+}
+
+// WriteJSON 以缩进 JSON 输出报告
+func (r *DeclineReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Slice 返回维度 d 上取值为 value 的统计，d 为 DimensionAll 时返回总计
+func (r *DeclineReport) Slice(d Dimension, value string) (ApprovalStats, bool) {
+	if d == DimensionAll {
+		return r.Total, true
+	}
+	for _, s := range r.Slices[d] {
+		if s.Value == value {
+			return s, true
+		}
+	}
+	return ApprovalStats{}, false
+}
+
+// isPaymentAttempt 交易是否为有结果的扣款尝试；退款交易的状态也可能是 settled，不计入通过率
+func isPaymentAttempt(t *funnelfox.Transaction) bool {
+	return (t.IsSettled() || t.IsDeclined()) && !t.IsRefund()
+}
+
+// approvalCounter 累计单个分组的统计
+type approvalCounter struct {
+	attempts, approved, declined int
+	reasons                      map[DeclineReason]int
+	messages                     map[DeclineReason]map[string]int
+}
+
+func (c *approvalCounter) add(t *funnelfox.Transaction) {
+	c.attempts++
+	if t.IsSettled() {
+		c.approved++
+		return
+	}
+	c.declined++
+	reason := declineReason(t)
+	if c.reasons == nil {
+		c.reasons = make(map[DeclineReason]int)
+		c.messages = make(map[DeclineReason]map[string]int)
+	}
+	c.reasons[reason]++
+	if t.PSPReasonMessage != nil && *t.PSPReasonMessage != "" {
+		if c.messages[reason] == nil {
+			c.messages[reason] = make(map[string]int)
+		}
+		c.messages[reason][*t.PSPReasonMessage]++
+	}
+}
+
+func (c *approvalCounter) stats(value string, topN int) ApprovalStats {
+	s := ApprovalStats{
+		Value:      value,
+		Attempts:   c.attempts,
+		Approved:   c.approved,
+		Declined:   c.declined,
+		TopReasons: []ReasonCount{},
+	}
+	if c.attempts > 0 {
+		s.ApprovalRate = float64(c.approved) / float64(c.attempts)
+	}
+	for reason, n := range c.reasons {
+		s.TopReasons = append(s.TopReasons, ReasonCount{
+			DeclineReason: reason,
+			Count:         n,
+			Message:       mostFrequent(c.messages[reason]),
+		})
+	}
+	sort.Slice(s.TopReasons, func(i, j int) bool {
+		a, b := s.TopReasons[i], s.TopReasons[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		return a.DeclineType < b.DeclineType
+	})
+	if topN > 0 && len(s.TopReasons) > topN {
+		s.TopReasons = s.TopReasons[:topN]
+	}
+	return s
+}
+
+func mostFrequent(counts map[string]int) string {
+	best, bestN := "", 0
+	for s, n := range counts {
+		if n > bestN || (n == bestN && s < best) {
+			best, bestN = s, n
+		}
+	}
+	return best
+}
+
+// Declines 统计成功和失败交易的通过率及主要拒付原因，退款交易不计入
+// topN: 每个分组保留的拒付原因数量，<= 0 时保留全部
+// dimensions: 分组维度，为空时使用 DeclineDimensions
+func Declines(transactions iter.Seq[funnelfox.Transaction], topN int, dimensions ...Dimension) *DeclineReport {
+	if len(dimensions) == 0 {
+		dimensions = DeclineDimensions
+	}
+	var total approvalCounter
+	counters := make(map[Dimension]map[string]*approvalCounter, len(dimensions))
+	for _, d := range dimensions {
+		counters[d] = make(map[string]*approvalCounter)
+	}
+
+	for t := range transactions {
+		if !isPaymentAttempt(&t) {
+			continue
+		}
+		total.add(&t)
+		for _, d := range dimensions {
+			v := dimensionValue(&t, d)
+			c := counters[d][v]
+			if c == nil {
+				c = &approvalCounter{}
+				counters[d][v] = c
+			}
+			c.add(&t)
+		}
+	}
+
+	report := &DeclineReport{
+		Total:  total.stats(string(DimensionAll), topN),
+		Slices: make(map[Dimension][]ApprovalStats, len(dimensions)),
+	}
+	for _, d := range dimensions {
+		slices := make([]ApprovalStats, 0, len(counters[d]))
+		for v, c := range counters[d] {
+			slices = append(slices, c.stats(v, topN))
+		}
+		sort.Slice(slices, func(i, j int) bool {
+			if slices[i].Attempts != slices[j].Attempts {
+				return slices[i].Attempts > slices[j].Attempts
+			}
+			return slices[i].Value < slices[j].Value
+		})
+		report.Slices[d] = slices
+	}
+	return report
+}
+
+// Spike 失败率显著上升的分组
+type Spike struct {
+	Dimension           Dimension `json:"dimension"`
+	Value               string    `json:"value"`
+	BaselineAttempts    int       `json:"baseline_attempts"`
+	BaselineDeclineRate float64   `json:"baseline_decline_rate"`
+	CurrentAttempts     int       `json:"current_attempts"`
+	CurrentDeclineRate  float64   `json:"current_decline_rate"`
+	Z                   float64   `json:"z"`
+	PValue              float64   `json:"p_value"`
+	// AdjustedPValue 经 Benjamini–Hochberg 多重比较校正后的 p 值
+	AdjustedPValue float64 `json:"adjusted_p_value"`
+}
+
+// DetectSpikes 对比两个时间窗口的拒付报告，使用单侧双比例 z 检验找出失败率显著上升的分组
+// minAttempts: 两个窗口中交易数量都不少于该值的分组才参与检验
+// alpha: 错误发现率（FDR），如 0.01；所有参与检验的分组一起按 Benjamini–Hochberg 校正，
+// 校正后的 p 值小于 alpha 的分组才报告为峰值
+// 结果按 z 值降序排列，包含总计（DimensionAll）
+func DetectSpikes(baseline, current *DeclineReport, minAttempts int, alpha float64) []Spike {
+	var tests []Spike
+	check := func(d Dimension, b, c ApprovalStats) {
+		if b.Attempts < minAttempts || c.Attempts < minAttempts {
+			return
+		}
+		z, p := twoProportionZTest(b.Declined, b.Attempts, c.Declined, c.Attempts)
+		tests = append(tests, Spike{
+			Dimension:           d,
+			Value:               c.Value,
+			BaselineAttempts:    b.Attempts,
+			BaselineDeclineRate: b.DeclineRate(),
+			CurrentAttempts:     c.Attempts,
+			CurrentDeclineRate:  c.DeclineRate(),
+			Z:                   z,
+			PValue:              p,
+		})
+	}
+
+	check(DimensionAll, baseline.Total, current.Total)
+	dimensions := make([]Dimension, 0, len(current.Slices))
+	for d := range current.Slices {
+		dimensions = append(dimensions, d)
+	}
+	sort.Slice(dimensions, func(i, j int) bool { return dimensions[i] < dimensions[j] })
+	for _, d := range dimensions {
+		for _, c := range current.Slices[d] {
+			if b, ok := baseline.Slice(d, c.Value); ok {
+				check(d, b, c)
+			}
+		}
+	}
+
+	adjustBenjaminiHochberg(tests)
+	var spikes []Spike
+	for _, s := range tests {
+		if s.Z > 0 && s.AdjustedPValue < alpha {
+			spikes = append(spikes, s)
+		}
+	}
+	sort.SliceStable(spikes, func(i, j int) bool { return spikes[i].Z > spikes[j].Z })
+	return spikes
+}
+
+// adjustBenjaminiHochberg 计算 Benjamini–Hochberg 校正后的 p 值：
+// 按 p 值升序第 i 个（从 1 开始）的校正值为 min_{j>=i}(m/j * p_j)，最大为 1
+func adjustBenjaminiHochberg(tests []Spike) {
+	m := len(tests)
+	order := make([]int, m)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return tests[order[i]].PValue < tests[order[j]].PValue })
+	adjusted := 1.0
+	for rank := m; rank >= 1; rank-- {
+		s := &tests[order[rank-1]]
+		adjusted = math.Min(adjusted, s.PValue*float64(m)/float64(rank))
+		s.AdjustedPValue = adjusted
+	}
+}
+
+// twoProportionZTest 检验比例 x2/n2 是否大于 x1/n1，返回 z 值和单侧 p 值
+func twoProportionZTest(x1, n1, x2, n2 int) (z, p float64) {
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+	p1 := float64(x1) / float64(n1)
+	p2 := float64(x2) / float64(n2)
+	pooled := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 0, 1
+	}
+	z = (p2 - p1) / se
+	return z, 0.5 * math.Erfc(z/math.Sqrt2)
+}
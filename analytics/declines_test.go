@@ -0,0 +1,161 @@
+package analytics
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// attempt 构造一笔扣款尝试，code 非空时为失败交易
+func attempt(psp, country, code string) funnelfox.Transaction {
+	t := funnelfox.Transaction{
+		PSP:                        psp,
+		Status:                     funnelfox.TransactionStatusSettled,
+		PMDataBinIssuerCountryCode: ptr(country),
+	}
+	if code != "" {
+		t.Status = funnelfox.TransactionStatusDeclined
+		t.PSPReasonType = ptr("issuer")
+		t.PSPReasonCode = ptr(code)
+		t.PSPReasonDeclineType = ptr("soft")
+		t.PSPReasonMessage = ptr("declined " + code)
+	}
+	return t
+}
+
+func TestDeclines(t *testing.T) {
+	transactions := []funnelfox.Transaction{
+		attempt("stripe", "US", ""),
+		attempt("stripe", "US", "51"),
+		attempt("stripe", "DE", "51"),
+		attempt("adyen", "", "05"),
+		refund("o1", "9.99", 0),
+		{Status: "pending"},
+	}
+	r := Declines(slices.Values(transactions), 1)
+
+	if r.Total.Attempts != 4 || r.Total.Approved != 1 || r.Total.Declined != 3 || r.Total.ApprovalRate != 0.25 {
+		t.Errorf("total = %+v", r.Total)
+	}
+	want := ReasonCount{DeclineReason: DeclineReason{Type: "issuer", Code: "51", DeclineType: "soft"}, Count: 2, Message: "declined 51"}
+	if len(r.Total.TopReasons) != 1 || r.Total.TopReasons[0] != want {
+		t.Errorf("top reasons = %+v, want [%+v]", r.Total.TopReasons, want)
+	}
+
+	tests := []struct {
+		dimension Dimension
+		value     string
+		attempts  int
+		approved  int
+	}{
+		{DimensionPSP, "stripe", 3, 1},
+		{DimensionPSP, "adyen", 1, 0},
+		{DimensionIssuerCountry, "US", 2, 1},
+		{DimensionIssuerCountry, unknownValue, 1, 0},
+		{DimensionCIT, "false", 4, 1},
+	}
+	for _, tt := range tests {
+		s, ok := r.Slice(tt.dimension, tt.value)
+		if !ok || s.Attempts != tt.attempts || s.Approved != tt.approved {
+			t.Errorf("slice %s=%s = %+v, %v, want attempts %d approved %d", tt.dimension, tt.value, s, ok, tt.attempts, tt.approved)
+		}
+	}
+	if got := r.Slices[DimensionPSP][0].Value; got != "stripe" {
+		t.Errorf("first psp slice = %s, want stripe", got)
+	}
+}
+
+// declineStats 构造分组统计
+func declineStats(value string, attempts, declined int) ApprovalStats {
+	return ApprovalStats{Value: value, Attempts: attempts, Approved: attempts - declined, Declined: declined}
+}
+
+func TestDetectSpikes(t *testing.T) {
+	// 16% vs 10%（200 笔）单独检验时 p ≈ 0.037
+	marginal := func(value string) (ApprovalStats, ApprovalStats) {
+		return declineStats(value, 200, 20), declineStats(value, 200, 32)
+	}
+	flat := func(value string) (ApprovalStats, ApprovalStats) {
+		return declineStats(value, 200, 20), declineStats(value, 200, 20)
+	}
+
+	tests := []struct {
+		name        string
+		slices      []func(string) (ApprovalStats, ApprovalStats)
+		total       [2]ApprovalStats
+		minAttempts int
+		want        []string
+	}{
+		{
+			name:   "significant increase",
+			slices: []func(string) (ApprovalStats, ApprovalStats){flat},
+			total:  [2]ApprovalStats{declineStats("all", 1000, 100), declineStats("all", 1000, 200)},
+			want:   []string{"all=all"},
+		},
+		{
+			name:   "small change",
+			total:  [2]ApprovalStats{declineStats("all", 100, 10), declineStats("all", 100, 11)},
+			slices: []func(string) (ApprovalStats, ApprovalStats){flat},
+		},
+		{
+			name:   "decrease is not a spike",
+			total:  [2]ApprovalStats{declineStats("all", 1000, 200), declineStats("all", 1000, 100)},
+			slices: []func(string) (ApprovalStats, ApprovalStats){flat},
+		},
+		{
+			name:        "single marginal slice",
+			total:       [2]ApprovalStats{declineStats("all", 100, 10), declineStats("all", 100, 10)},
+			slices:      []func(string) (ApprovalStats, ApprovalStats){marginal},
+			minAttempts: 150,
+			want:        []string{"psp=p0"},
+		},
+		{
+			name:        "marginal slice among many is corrected away",
+			total:       [2]ApprovalStats{declineStats("all", 100, 10), declineStats("all", 100, 10)},
+			slices:      append([]func(string) (ApprovalStats, ApprovalStats){marginal}, slices.Repeat([]func(string) (ApprovalStats, ApprovalStats){flat}, 19)...),
+			minAttempts: 150,
+		},
+		{
+			name:        "below min attempts",
+			total:       [2]ApprovalStats{declineStats("all", 1000, 100), declineStats("all", 1000, 200)},
+			minAttempts: 2000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseline := &DeclineReport{Total: tt.total[0], Slices: map[Dimension][]ApprovalStats{}}
+			current := &DeclineReport{Total: tt.total[1], Slices: map[Dimension][]ApprovalStats{}}
+			for i, s := range tt.slices {
+				b, c := s(fmt.Sprintf("p%d", i))
+				baseline.Slices[DimensionPSP] = append(baseline.Slices[DimensionPSP], b)
+				current.Slices[DimensionPSP] = append(current.Slices[DimensionPSP], c)
+			}
+
+			spikes := DetectSpikes(baseline, current, tt.minAttempts, 0.05)
+			var got []string
+			for _, s := range spikes {
+				got = append(got, string(s.Dimension)+"="+s.Value)
+				if s.AdjustedPValue < s.PValue {
+					t.Errorf("%s adjusted p = %v < p = %v", s.Value, s.AdjustedPValue, s.PValue)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("spikes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjustBenjaminiHochberg(t *testing.T) {
+	tests := []Spike{{PValue: 0.01}, {PValue: 0.04}, {PValue: 0.03}, {PValue: 0.5}}
+	adjustBenjaminiHochberg(tests)
+	// 升序 0.01, 0.03, 0.04, 0.5 -> 0.04, 0.04*4/3, 0.04*4/3, 0.5
+	want := []float64{0.04, 0.04 * 4 / 3, 0.04 * 4 / 3, 0.5}
+	for i, s := range tests {
+		if diff := s.AdjustedPValue - want[i]; diff > 1e-12 || diff < -1e-12 {
+			t.Errorf("tests[%d] adjusted = %v, want %v", i, s.AdjustedPValue, want[i])
+		}
+	}
+}
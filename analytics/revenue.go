@@ -0,0 +1,306 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// daysPerMonth 平均每月天数（365.25/12 = 1461/48），用于按天、周、分钟计费的价格点折算 MRR
+var daysPerMonth = big.NewRat(1461, 48)
+
+// MonthsPerPeriod 返回一个计费周期折合的月数，单位未知或周期不为正时返回 false
+func MonthsPerPeriod(period int, unit funnelfox.PeriodDurationUnit) (*big.Rat, bool) {
+	if period <= 0 {
+		return nil, false
+	}
+	n := big.NewRat(int64(period), 1)
+	switch unit {
+	case funnelfox.PeriodDurationUnitMinutes:
+		return n.Quo(n, new(big.Rat).Mul(daysPerMonth, big.NewRat(24*60, 1))), true
+	case funnelfox.PeriodDurationUnitDays:
+		return n.Quo(n, daysPerMonth), true
+	case funnelfox.PeriodDurationUnitWeeks:
+		return n.Quo(n.Mul(n, big.NewRat(7, 1)), daysPerMonth), true
+	case funnelfox.PeriodDurationUnitMonths:
+		return n, true
+	case funnelfox.PeriodDurationUnitYears:
+		return n.Mul(n, big.NewRat(12, 1)), true
+	}
+	return nil, false
+}
+
+// periodEnd 计费周期结束时间
+func periodEnd(start time.Time, period int, unit funnelfox.PeriodDurationUnit) time.Time {
+	switch unit {
+	case funnelfox.PeriodDurationUnitMinutes:
+		return start.Add(time.Duration(period) * time.Minute)
+	case funnelfox.PeriodDurationUnitDays:
+		return start.AddDate(0, 0, period)
+	case funnelfox.PeriodDurationUnitWeeks:
+		return start.AddDate(0, 0, 7*period)
+	case funnelfox.PeriodDurationUnitMonths:
+		return start.AddDate(0, period, 0)
+	case funnelfox.PeriodDurationUnitYears:
+		return start.AddDate(period, 0, 0)
+	}
+	return start
+}
+
+// Totals 收入汇总，金额单位为美元（AmountUSD）
+type Totals struct {
+	Settled  int    `json:"settled"`  // 成功交易数量
+	Refunds  int    `json:"refunds"`  // 退款交易数量
+	Gross    Amount `json:"gross"`    // 成功交易金额
+	Refunded Amount `json:"refunded"` // 退款金额
+	Net      Amount `json:"net"`      // Gross - Refunded
+	MRR      Amount `json:"mrr"`      // 月经常性收入
+	ARR      Amount `json:"arr"`      // MRR * 12
+}
+
+// CurrencyTotals 按交易货币汇总，同时给出原币种金额
+type CurrencyTotals struct {
+	Totals
+	LocalGross    Amount `json:"local_gross"`
+	LocalRefunded Amount `json:"local_refunded"`
+	LocalNet      Amount `json:"local_net"`
+	LocalMRR      Amount `json:"local_mrr"`
+}
+
+// RevenueReport 收入报告
+type RevenueReport struct {
+	AsOf         time.Time                  `json:"as_of"` // MRR 计算时间点
+	Transactions int                        `json:"transactions"`
+	Total        Totals                     `json:"total"`
+	ByPricePoint map[int]*Totals            `json:"by_price_point"`
+	ByRegion     map[string]*Totals         `json:"by_region"`
+	ByCurrency   map[string]*CurrencyTotals `json:"by_currency"`
+	// ActiveSubscriptions 计入 MRR 的订阅数量
+	ActiveSubscriptions int `json:"active_subscriptions"`
+	// IntroSubscriptions 最近一次扣款为付费试用、未计入 MRR 的订阅数量
+	IntroSubscriptions int `json:"intro_subscriptions"`
+	// UnparsedAmounts AmountUSD 无法解析而被跳过的交易数量
+	UnparsedAmounts int `json:"unparsed_amounts"`
+	// UnknownPricePoints 没有提供定义、未计入 MRR 的价格点ID
+	UnknownPricePoints []int `json:"unknown_price_points"`
+}
+
+// WriteJSON 以缩进 JSON 输出报告
+func (r *RevenueReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// subscriptionCharge 订阅最近一次成功扣款
+type subscriptionCharge struct {
+	at        time.Time
+	orderID   string
+	ppID      int
+	region    string
+	currency  string
+	amountUSD *big.Rat
+	amount    *big.Rat
+}
+
+// Revenue 计算收入报告
+//
+// pricePoints 以价格点ID（Transaction.PPID）为键，用于 MRR 折算，可以通过 PricePointsByID 从 ListPricePoints 的结果构建。
+// 退款交易（Transaction.IsRefund）计入 Refunded，其余成功交易计入 Gross。
+// MRR 取每个订阅在 asOf 时最近一次成功扣款，扣款所在周期（NextPeriod/NextPeriodDuration）覆盖 asOf
+// 且订单未退款时，按扣款金额折算为月金额计入；付费试用扣款、一次性购买和非订阅价格点不计入 MRR。
+// asOf 为零值时使用最后一笔交易的时间。
+func Revenue(transactions iter.Seq[funnelfox.Transaction], pricePoints map[int]funnelfox.PricePoint, asOf time.Time) *RevenueReport {
+	report := &RevenueReport{
+		ByPricePoint: make(map[int]*Totals),
+		ByRegion:     make(map[string]*Totals),
+		ByCurrency:   make(map[string]*CurrencyTotals),
+	}
+	latest := make(map[string]subscriptionCharge)
+	refundedOrders := make(map[string]bool)
+	var lastSeen time.Time
+
+	for t := range transactions {
+		report.Transactions++
+		if t.TrxCreatedAt != nil && t.TrxCreatedAt.After(lastSeen) {
+			lastSeen = *t.TrxCreatedAt
+		}
+		refund := t.IsRefund() && !t.IsDeclined()
+		if !refund && !t.IsSettled() {
+			continue
+		}
+		usd, ok := parseAmount(t.AmountUSD)
+		if !ok {
+			report.UnparsedAmounts++
+			continue
+		}
+		local, ok := parseAmount(t.Amount)
+		if !ok {
+			local = new(big.Rat)
+		}
+
+		cur := report.currency(t.CurrencyCode)
+		for _, totals := range []*Totals{&report.Total, report.pricePoint(t.PPID), report.region(t.Region), &cur.Totals} {
+			if refund {
+				totals.Refunds++
+				totals.Refunded.add(new(big.Rat).Abs(usd))
+			} else {
+				totals.Settled++
+				totals.Gross.add(usd)
+			}
+		}
+		if refund {
+			cur.LocalRefunded.add(new(big.Rat).Abs(local))
+			refundedOrders[t.OrderID] = true
+			continue
+		}
+		cur.LocalGross.add(local)
+
+		if t.MetaSubsID == nil || *t.MetaSubsID == "" || t.MetaOneoffID != nil || t.TrxCreatedAt == nil || usd.Sign() <= 0 {
+			continue
+		}
+		if prev, ok := latest[*t.MetaSubsID]; ok && prev.at.After(*t.TrxCreatedAt) {
+			continue
+		}
+		latest[*t.MetaSubsID] = subscriptionCharge{
+			at:        *t.TrxCreatedAt,
+			orderID:   t.OrderID,
+			ppID:      t.PPID,
+			region:    t.Region,
+			currency:  t.CurrencyCode,
+			amountUSD: usd,
+			amount:    local,
+		}
+	}
+
+	if asOf.IsZero() {
+		asOf = lastSeen
+	}
+	report.AsOf = asOf
+
+	unknown := make(map[int]bool)
+	for _, charge := range latest {
+		if charge.at.After(asOf) || refundedOrders[charge.orderID] {
+			continue
+		}
+		pp, ok := pricePoints[charge.ppID]
+		if !ok {
+			unknown[charge.ppID] = true
+			continue
+		}
+		if pp.NextPeriod == nil || pp.NextPeriodDuration == nil {
+			continue
+		}
+		if isIntroCharge(pp, charge.amount) {
+			report.IntroSubscriptions++
+			continue
+		}
+		months, ok := MonthsPerPeriod(*pp.NextPeriod, *pp.NextPeriodDuration)
+		if !ok || !periodEnd(charge.at, *pp.NextPeriod, *pp.NextPeriodDuration).After(asOf) {
+			continue
+		}
+		mrr := new(big.Rat).Quo(charge.amountUSD, months)
+		report.ActiveSubscriptions++
+		cur := report.currency(charge.currency)
+		for _, totals := range []*Totals{&report.Total, report.pricePoint(charge.ppID), report.region(charge.region), &cur.Totals} {
+			totals.MRR.add(mrr)
+		}
+		cur.LocalMRR.add(new(big.Rat).Quo(charge.amount, months))
+	}
+	for id := range unknown {
+		report.UnknownPricePoints = append(report.UnknownPricePoints, id)
+	}
+	sort.Ints(report.UnknownPricePoints)
+
+	for _, totals := range report.all() {
+		totals.Net = amountOf(new(big.Rat).Sub(totals.Gross.Rat(), totals.Refunded.Rat()))
+		totals.ARR = amountOf(new(big.Rat).Mul(totals.MRR.Rat(), big.NewRat(12, 1)))
+	}
+	for _, cur := range report.ByCurrency {
+		cur.LocalNet = amountOf(new(big.Rat).Sub(cur.LocalGross.Rat(), cur.LocalRefunded.Rat()))
+	}
+	return report
+}
+
+// isIntroCharge 扣款是否为付费试用：价格点有付费试用，扣款金额等于试用价且不等于续费价
+func isIntroCharge(pp funnelfox.PricePoint, amount *big.Rat) bool {
+	if pp.IntroType != funnelfox.IntroTypePaidTrial || pp.IntroPaidTrialPrice == nil {
+		return false
+	}
+	intro, ok := parseAmount(*pp.IntroPaidTrialPrice)
+	if !ok || intro.Cmp(amount) != 0 {
+		return false
+	}
+	if pp.NextPrice != nil {
+		if next, ok := parseAmount(*pp.NextPrice); ok && next.Cmp(amount) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrNoPricePointIDs 价格点列表不为空但都没有ID，无法与 Transaction.PPID 关联
+var ErrNoPricePointIDs = errors.New("analytics: no price point has an id")
+
+// PricePointsByID 以价格点ID为键索引价格点，没有ID的价格点被忽略
+//
+// API 文档没有列出价格点的 id 字段，所有价格点都没有ID时返回 ErrNoPricePointIDs，
+// 避免 MRR 因为无法关联价格点而静默为 0
+//
+//	res, err := client.ListPricePoints(funnelfox.PricePointsListRequest{})
+//	pricePoints, err := analytics.PricePointsByID(res.PricePoints)
+//	report := analytics.Revenue(seq, pricePoints, time.Now())
+func PricePointsByID(pricePoints []funnelfox.PricePoint) (map[int]funnelfox.PricePoint, error) {
+	res := make(map[int]funnelfox.PricePoint, len(pricePoints))
+	for _, pp := range pricePoints {
+		if pp.ID != nil {
+			res[*pp.ID] = pp
+		}
+	}
+	if len(pricePoints) > 0 && len(res) == 0 {
+		return nil, ErrNoPricePointIDs
+	}
+	return res, nil
+}
+
+func (r *RevenueReport) pricePoint(id int) *Totals {
+	if r.ByPricePoint[id] == nil {
+		r.ByPricePoint[id] = &Totals{}
+	}
+	return r.ByPricePoint[id]
+}
+
+func (r *RevenueReport) region(region string) *Totals {
+	if r.ByRegion[region] == nil {
+		r.ByRegion[region] = &Totals{}
+	}
+	return r.ByRegion[region]
+}
+
+func (r *RevenueReport) currency(code string) *CurrencyTotals {
+	if r.ByCurrency[code] == nil {
+		r.ByCurrency[code] = &CurrencyTotals{}
+	}
+	return r.ByCurrency[code]
+}
+
+// all 返回报告中的所有汇总
+func (r *RevenueReport) all() []*Totals {
+	res := []*Totals{&r.Total}
+	for _, t := range r.ByPricePoint {
+		res = append(res, t)
+	}
+	for _, t := range r.ByRegion {
+		res = append(res, t)
+	}
+	for _, t := range r.ByCurrency {
+		res = append(res, &t.Totals)
+	}
+	return res
+}
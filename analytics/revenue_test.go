@@ -0,0 +1,289 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byte-power/funnelfox"
+)
+
+var day0 = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+func ptr[T any](v T) *T { return &v }
+
+// charge 构造成功的订阅扣款，day 为相对 day0 的天数
+func charge(order, subs string, ppID int, amountUSD string, day int) funnelfox.Transaction {
+	return funnelfox.Transaction{
+		OrderID:      order,
+		TrxID:        "t-" + order,
+		PPID:         ppID,
+		Status:       funnelfox.TransactionStatusSettled,
+		Region:       "US",
+		Amount:       amountUSD,
+		AmountUSD:    amountUSD,
+		CurrencyCode: "USD",
+		MetaSubsID:   ptr(subs),
+		TrxCreatedAt: ptr(day0.AddDate(0, 0, day)),
+	}
+}
+
+func refund(order, amountUSD string, day int) funnelfox.Transaction {
+	return funnelfox.Transaction{
+		OrderID:            order,
+		TrxID:              "r-" + order,
+		Status:             funnelfox.TransactionStatusSettled,
+		PSPTransactionType: "REFUND",
+		Region:             "US",
+		Amount:             "-" + amountUSD,
+		AmountUSD:          "-" + amountUSD,
+		CurrencyCode:       "USD",
+		TrxCreatedAt:       ptr(day0.AddDate(0, 0, day)),
+	}
+}
+
+func recurring(price string, period int, unit funnelfox.PeriodDurationUnit) funnelfox.PricePoint {
+	return funnelfox.PricePoint{
+		IntroType:          funnelfox.IntroTypeNoIntro,
+		NextPrice:          ptr(price),
+		NextPeriod:         ptr(period),
+		NextPeriodDuration: ptr(unit),
+	}
+}
+
+func paidTrial(intro, price string) funnelfox.PricePoint {
+	pp := recurring(price, 1, funnelfox.PeriodDurationUnitMonths)
+	pp.IntroType = funnelfox.IntroTypePaidTrial
+	pp.IntroPaidTrialPrice = ptr(intro)
+	pp.IntroPaidTrialPeriod = ptr(7)
+	pp.IntroPaidTrialPeriodDuration = ptr(funnelfox.PeriodDurationUnitDays)
+	return pp
+}
+
+func TestRevenue(t *testing.T) {
+	pricePoints := map[int]funnelfox.PricePoint{
+		1: recurring("9.99", 1, funnelfox.PeriodDurationUnitMonths),
+		2: recurring("120", 1, funnelfox.PeriodDurationUnitYears),
+		3: recurring("7", 1, funnelfox.PeriodDurationUnitWeeks),
+		4: paidTrial("1.00", "9.99"),
+	}
+	tests := []struct {
+		name         string
+		transactions []funnelfox.Transaction
+		asOf         time.Time
+		wantGross    string
+		wantRefunded string
+		wantNet      string
+		wantMRR      string
+		wantARR      string
+		wantActive   int
+		wantIntro    int
+		wantUnknown  []int
+	}{
+		{
+			name: "exact sums",
+			transactions: []funnelfox.Transaction{
+				charge("o1", "s1", 99, "0.1", 0),
+				charge("o2", "s2", 99, "0.2", 0),
+			},
+			wantGross: "0.3", wantRefunded: "0", wantNet: "0.3", wantMRR: "0", wantARR: "0",
+			wantUnknown: []int{99},
+		},
+		{
+			name:         "monthly yearly and weekly normalized",
+			transactions: []funnelfox.Transaction{charge("o1", "s1", 1, "9.99", 0), charge("o2", "s2", 2, "120", 0), charge("o3", "s3", 3, "7", 0)},
+			asOf:         day0.AddDate(0, 0, 3),
+			wantGross:    "136.99", wantRefunded: "0", wantNet: "136.99",
+			// 9.99 + 120/12 + 7*1461/(7*48)
+			wantMRR: "50.4275", wantARR: "605.13",
+			wantActive: 3,
+		},
+		{
+			name:         "latest charge per subscription",
+			transactions: []funnelfox.Transaction{charge("o1", "s1", 1, "9.99", 0), charge("o2", "s1", 2, "120", 20)},
+			asOf:         day0.AddDate(0, 0, 25),
+			wantGross:    "129.99", wantRefunded: "0", wantNet: "129.99", wantMRR: "10", wantARR: "120",
+			wantActive: 1,
+		},
+		{
+			name:         "expired period not counted",
+			transactions: []funnelfox.Transaction{charge("o1", "s1", 1, "9.99", 0)},
+			asOf:         day0.AddDate(0, 0, 40),
+			wantGross:    "9.99", wantRefunded: "0", wantNet: "9.99", wantMRR: "0", wantARR: "0",
+		},
+		{
+			name:         "refunded order not counted",
+			transactions: []funnelfox.Transaction{charge("o1", "s1", 1, "9.99", 0), refund("o1", "9.99", 1)},
+			asOf:         day0.AddDate(0, 0, 3),
+			wantGross:    "9.99", wantRefunded: "9.99", wantNet: "0", wantMRR: "0", wantARR: "0",
+		},
+		{
+			name:         "paid trial charge excluded",
+			transactions: []funnelfox.Transaction{charge("o1", "s1", 4, "1.00", 0)},
+			asOf:         day0.AddDate(0, 0, 3),
+			wantGross:    "1", wantRefunded: "0", wantNet: "1", wantMRR: "0", wantARR: "0",
+			wantIntro: 1,
+		},
+		{
+			name:         "renewal after paid trial counted",
+			transactions: []funnelfox.Transaction{charge("o1", "s1", 4, "1.00", 0), charge("o2", "s1", 4, "9.99", 7)},
+			asOf:         day0.AddDate(0, 0, 10),
+			wantGross:    "10.99", wantRefunded: "0", wantNet: "10.99", wantMRR: "9.99", wantARR: "119.88",
+			wantActive: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Revenue(slices.Values(tt.transactions), pricePoints, tt.asOf)
+			for _, c := range []struct {
+				name string
+				got  Amount
+				want string
+			}{
+				{"gross", r.Total.Gross, tt.wantGross},
+				{"refunded", r.Total.Refunded, tt.wantRefunded},
+				{"net", r.Total.Net, tt.wantNet},
+				{"mrr", r.Total.MRR, tt.wantMRR},
+				{"arr", r.Total.ARR, tt.wantARR},
+			} {
+				if c.got.String() != c.want {
+					t.Errorf("%s = %s, want %s", c.name, c.got, c.want)
+				}
+			}
+			if r.ActiveSubscriptions != tt.wantActive || r.IntroSubscriptions != tt.wantIntro {
+				t.Errorf("active/intro = %d/%d, want %d/%d", r.ActiveSubscriptions, r.IntroSubscriptions, tt.wantActive, tt.wantIntro)
+			}
+			if !slices.Equal(r.UnknownPricePoints, tt.wantUnknown) {
+				t.Errorf("unknown price points = %v, want %v", r.UnknownPricePoints, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestRevenueBreakdowns(t *testing.T) {
+	eur := charge("o2", "s2", 1, "10.80", 0)
+	eur.Amount = "9.99"
+	eur.CurrencyCode = "EUR"
+	eur.Region = "DE"
+	transactions := []funnelfox.Transaction{charge("o1", "s1", 1, "9.99", 0), eur}
+	pricePoints := map[int]funnelfox.PricePoint{1: recurring("9.99", 1, funnelfox.PeriodDurationUnitMonths)}
+
+	r := Revenue(slices.Values(transactions), pricePoints, day0.AddDate(0, 0, 1))
+	checks := []struct {
+		name string
+		got  Amount
+		want string
+	}{
+		{"by_price_point[1].mrr", r.ByPricePoint[1].MRR, "20.79"},
+		{"by_region[DE].gross", r.ByRegion["DE"].Gross, "10.8"},
+		{"by_currency[EUR].mrr", r.ByCurrency["EUR"].MRR, "10.8"},
+		{"by_currency[EUR].local_mrr", r.ByCurrency["EUR"].LocalMRR, "9.99"},
+		{"by_currency[EUR].local_net", r.ByCurrency["EUR"].LocalNet, "9.99"},
+	}
+	for _, c := range checks {
+		if c.got.String() != c.want {
+			t.Errorf("%s = %s, want %s", c.name, c.got, c.want)
+		}
+	}
+
+	var buf strings.Builder
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"mrr": "20.79"`) {
+		t.Errorf("WriteJSON output missing decimal mrr:\n%s", buf.String())
+	}
+}
+
+func TestMonthsPerPeriod(t *testing.T) {
+	tests := []struct {
+		period int
+		unit   funnelfox.PeriodDurationUnit
+		want   string
+		ok     bool
+	}{
+		{1, funnelfox.PeriodDurationUnitMonths, "1", true},
+		{3, funnelfox.PeriodDurationUnitMonths, "3", true},
+		{1, funnelfox.PeriodDurationUnitYears, "12", true},
+		{1, funnelfox.PeriodDurationUnitWeeks, "112/487", true},
+		{1461, funnelfox.PeriodDurationUnitDays, "48", true},
+		{0, funnelfox.PeriodDurationUnitMonths, "", false},
+		{1, "fortnights", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := MonthsPerPeriod(tt.period, tt.unit)
+		if ok != tt.ok {
+			t.Errorf("MonthsPerPeriod(%d, %s) ok = %v", tt.period, tt.unit, ok)
+			continue
+		}
+		if ok && got.RatString() != tt.want {
+			t.Errorf("MonthsPerPeriod(%d, %s) = %s, want %s", tt.period, tt.unit, got.RatString(), tt.want)
+		}
+	}
+}
+
+func TestPricePointsByID(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[int]string
+		wantErr error
+	}{
+		{
+			name: "price points without id ignored",
+			data: `{"price_points":[{"id":7,"ident":"pro_monthly"},{"ident":"legacy"}]}`,
+			want: map[int]string{7: "pro_monthly"},
+		},
+		{
+			name: "empty list",
+			data: `{"price_points":[]}`,
+			want: map[int]string{},
+		},
+		{
+			name:    "no price point has id",
+			data:    `{"price_points":[{"ident":"pro_monthly"},{"ident":"legacy"}]}`,
+			wantErr: ErrNoPricePointIDs,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res funnelfox.PricePointsListResponse
+			if err := json.Unmarshal([]byte(tt.data), &res); err != nil {
+				t.Fatal(err)
+			}
+			got, err := PricePointsByID(res.PricePoints)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PricePointsByID() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("PricePointsByID() = %+v, want %v", got, tt.want)
+			}
+			for id, ident := range tt.want {
+				if got[id].Ident != ident {
+					t.Errorf("PricePointsByID()[%d].Ident = %q, want %q", id, got[id].Ident, ident)
+				}
+			}
+		})
+	}
+}
+
+func TestAmount(t *testing.T) {
+	var a Amount
+	if a.String() != "0" {
+		t.Errorf("zero Amount = %s", a)
+	}
+	a.add(big.NewRat(1, 3))
+	b := a
+	b.add(big.NewRat(1, 1))
+	if a.String() != "0.333333" || b.String() != "1.333333" {
+		t.Errorf("a = %s, b = %s", a, b)
+	}
+	out, err := json.Marshal(amountOf(big.NewRat(-5, 2)))
+	if err != nil || string(out) != `"-2.5"` {
+		t.Errorf("MarshalJSON = %s, %v", out, err)
+	}
+}
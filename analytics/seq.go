@@ -0,0 +1,38 @@
+// Package analytics 基于交易报告计算收入、拒付和 3DS 等统计指标
+package analytics
+
+import (
+	"iter"
+	"math/big"
+	"strings"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// Transactions 将 Client.Transactions 返回的迭代器转换为 iter.Seq，遍历遇到错误时停止，
+// 错误通过返回的函数在遍历结束后获取
+//
+//	seq, errf := analytics.Transactions(client.Transactions(req))
+//	report := analytics.Revenue(seq, pricePoints, time.Now())
+//	if err := errf(); err != nil { ... }
+func Transactions(seq iter.Seq2[funnelfox.Transaction, *funnelfox.Error]) (iter.Seq[funnelfox.Transaction], func() *funnelfox.Error) {
+	var failed *funnelfox.Error
+	return func(yield func(funnelfox.Transaction) bool) {
+			for t, err := range seq {
+				if err != nil {
+					failed = err
+					return
+				}
+				if !yield(t) {
+					return
+				}
+			}
+		}, func() *funnelfox.Error {
+			return failed
+		}
+}
+
+// parseAmount 解析十进制金额字符串
+func parseAmount(s string) (*big.Rat, bool) {
+	return new(big.Rat).SetString(strings.TrimSpace(s))
+}
@@ -0,0 +1,206 @@
+package analytics
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+	"sort"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// ThreeDSDimensions 3DS 报告默认的分组维度
+var ThreeDSDimensions = []Dimension{
+	DimensionIssuerCountry,
+	DimensionPSP,
+	DimensionThreeDSVersion,
+}
+
+// ThreeDSStats 3DS 统计
+//
+// 发起 3DS 指交易带有 3DS 协议版本、挑战标记或响应码；
+// 无感验证（frictionless）指发起 3DS 但未发出挑战
+type ThreeDSStats struct {
+	Value                     string  `json:"value"`
+	Attempts                  int     `json:"attempts"`   // 成功和失败交易数量
+	ThreeDS                   int     `json:"threeds"`    // 发起 3DS 的交易数量
+	Challenged                int     `json:"challenged"` // 发出挑战的交易数量
+	Frictionless              int     `json:"frictionless"`
+	ChallengedApproved        int     `json:"challenged_approved"`
+	FrictionlessApproved      int     `json:"frictionless_approved"`
+	NonChallenged             int     `json:"non_challenged"` // 未发出挑战（含未发起 3DS）的交易数量
+	NonChallengedApproved     int     `json:"non_challenged_approved"`
+	ChallengeRate             float64 `json:"challenge_rate"`               // Challenged / ThreeDS
+	FrictionlessRate          float64 `json:"frictionless_rate"`            // Frictionless / ThreeDS
+	ChallengeSuccessRate      float64 `json:"challenge_success_rate"`       // ChallengedApproved / Challenged
+	FrictionlessApprovalRate  float64 `json:"frictionless_approval_rate"`   // FrictionlessApproved / Frictionless
+	NonChallengedApprovalRate float64 `json:"non_challenged_approval_rate"` // NonChallengedApproved / NonChallenged
+	// ApprovalRateDiff 挑战与未挑战交易的通过率差（ChallengeSuccessRate - NonChallengedApprovalRate），
+	// 负值表示挑战带来的转化损失
+	ApprovalRateDiff float64 `json:"approval_rate_diff"`
+}
+
+// CodeCount 3DS 响应码或原因码的出现次数
+type CodeCount struct {
+	Code     string `json:"code"`
+	Count    int    `json:"count"`
+	Approved int    `json:"approved"`
+}
+
+// ThreeDSReport 3DS 报告
+type ThreeDSReport struct {
+	Total ThreeDSStats `json:"total"`
+	// Slices 各维度的分组统计，按交易数量降序
+	Slices map[Dimension][]ThreeDSStats `json:"slices"`
+	// ResponseCodes 发起 3DS 的交易的 ThreeDSResponseCode 分布，按次数降序
+	ResponseCodes []CodeCount `json:"response_codes"`
+	// ReasonCodes 发起 3DS 的交易的 ThreeDSReasonCode 分布，按次数降序
+	ReasonCodes []CodeCount `json:"reason_codes"`
+}
+
+// WriteJSON 以缩进 JSON 输出报告
+func (r *ThreeDSReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// usesThreeDS 交易是否发起了 3DS
+func usesThreeDS(t *funnelfox.Transaction) bool {
+	return t.ThreeDSChallengeIssued != nil || t.ThreeDSProtocolVersion != nil || t.ThreeDSResponseCode != nil
+}
+
+// threeDSCounter 累计单个分组的统计
+type threeDSCounter struct {
+	stats ThreeDSStats
+}
+
+func (c *threeDSCounter) add(t *funnelfox.Transaction) {
+	s := &c.stats
+	approved := t.IsSettled()
+	challenged := t.ThreeDSChallengeIssued != nil && *t.ThreeDSChallengeIssued
+	s.Attempts++
+	if usesThreeDS(t) {
+		s.ThreeDS++
+		if !challenged {
+			s.Frictionless++
+			if approved {
+				s.FrictionlessApproved++
+			}
+		}
+	}
+	if challenged {
+		s.Challenged++
+		if approved {
+			s.ChallengedApproved++
+		}
+		return
+	}
+	s.NonChallenged++
+	if approved {
+		s.NonChallengedApproved++
+	}
+}
+
+func (c *threeDSCounter) result(value string) ThreeDSStats {
+	s := c.stats
+	s.Value = value
+	s.ChallengeRate = ratio(s.Challenged, s.ThreeDS)
+	s.FrictionlessRate = ratio(s.Frictionless, s.ThreeDS)
+	s.ChallengeSuccessRate = ratio(s.ChallengedApproved, s.Challenged)
+	s.FrictionlessApprovalRate = ratio(s.FrictionlessApproved, s.Frictionless)
+	s.NonChallengedApprovalRate = ratio(s.NonChallengedApproved, s.NonChallenged)
+	if s.Challenged > 0 && s.NonChallenged > 0 {
+		s.ApprovalRateDiff = s.ChallengeSuccessRate - s.NonChallengedApprovalRate
+	}
+	return s
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// ThreeDS 统计 3DS 挑战率、无感验证率、挑战后成功率，以及挑战与未挑战交易的通过率差，退款交易不计入
+// dimensions: 分组维度，为空时使用 ThreeDSDimensions
+func ThreeDS(transactions iter.Seq[funnelfox.Transaction], dimensions ...Dimension) *ThreeDSReport {
+	if len(dimensions) == 0 {
+		dimensions = ThreeDSDimensions
+	}
+	var total threeDSCounter
+	counters := make(map[Dimension]map[string]*threeDSCounter, len(dimensions))
+	for _, d := range dimensions {
+		counters[d] = make(map[string]*threeDSCounter)
+	}
+	responseCodes := make(map[string]*CodeCount)
+	reasonCodes := make(map[string]*CodeCount)
+
+	for t := range transactions {
+		if !isPaymentAttempt(&t) {
+			continue
+		}
+		total.add(&t)
+		for _, d := range dimensions {
+			v := dimensionValue(&t, d)
+			c := counters[d][v]
+			if c == nil {
+				c = &threeDSCounter{}
+				counters[d][v] = c
+			}
+			c.add(&t)
+		}
+		if usesThreeDS(&t) {
+			countCode(responseCodes, orUnknown(t.ThreeDSResponseCode), t.IsSettled())
+			countCode(reasonCodes, orUnknown(t.ThreeDSReasonCode), t.IsSettled())
+		}
+	}
+
+	report := &ThreeDSReport{
+		Total:         total.result(string(DimensionAll)),
+		Slices:        make(map[Dimension][]ThreeDSStats, len(dimensions)),
+		ResponseCodes: sortedCodes(responseCodes),
+		ReasonCodes:   sortedCodes(reasonCodes),
+	}
+	for _, d := range dimensions {
+		slices := make([]ThreeDSStats, 0, len(counters[d]))
+		for v, c := range counters[d] {
+			slices = append(slices, c.result(v))
+		}
+		sort.Slice(slices, func(i, j int) bool {
+			if slices[i].Attempts != slices[j].Attempts {
+				return slices[i].Attempts > slices[j].Attempts
+			}
+			return slices[i].Value < slices[j].Value
+		})
+		report.Slices[d] = slices
+	}
+	return report
+}
+
+func countCode(counts map[string]*CodeCount, code string, approved bool) {
+	c := counts[code]
+	if c == nil {
+		c = &CodeCount{Code: code}
+		counts[code] = c
+	}
+	c.Count++
+	if approved {
+		c.Approved++
+	}
+}
+
+func sortedCodes(counts map[string]*CodeCount) []CodeCount {
+	res := make([]CodeCount, 0, len(counts))
+	for _, c := range counts {
+		res = append(res, *c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Code < res[j].Code
+	})
+	return res
+}
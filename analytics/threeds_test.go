@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// threeDSAttempt 构造一笔扣款尝试；challenge 为 nil 且 response 为空时表示未发起 3DS
+func threeDSAttempt(psp string, challenge *bool, response string, approved bool) funnelfox.Transaction {
+	t := funnelfox.Transaction{PSP: psp, Status: funnelfox.TransactionStatusDeclined}
+	if approved {
+		t.Status = funnelfox.TransactionStatusSettled
+	}
+	t.ThreeDSChallengeIssued = challenge
+	if response != "" {
+		t.ThreeDSProtocolVersion = ptr("2.2.0")
+		t.ThreeDSResponseCode = ptr(response)
+	}
+	return t
+}
+
+func TestThreeDS(t *testing.T) {
+	challenged, frictionless := ptr(true), ptr(false)
+	refunded := threeDSAttempt("stripe", frictionless, "Y", true)
+	refunded.PSPTransactionType = "REFUND"
+
+	tests := []struct {
+		name         string
+		transactions []funnelfox.Transaction
+		want         ThreeDSStats
+	}{
+		{
+			name: "challenge costs approvals",
+			transactions: []funnelfox.Transaction{
+				threeDSAttempt("stripe", challenged, "C", true),
+				threeDSAttempt("stripe", challenged, "C", false),
+				threeDSAttempt("stripe", challenged, "N", false),
+				threeDSAttempt("stripe", challenged, "N", false),
+				threeDSAttempt("stripe", frictionless, "Y", true),
+				threeDSAttempt("stripe", frictionless, "Y", true),
+				threeDSAttempt("stripe", nil, "", true),
+				threeDSAttempt("stripe", nil, "", false),
+			},
+			want: ThreeDSStats{
+				Attempts: 8, ThreeDS: 6, Challenged: 4, Frictionless: 2,
+				ChallengedApproved: 1, FrictionlessApproved: 2, NonChallenged: 4, NonChallengedApproved: 3,
+				ChallengeRate: 4.0 / 6, FrictionlessRate: 2.0 / 6,
+				ChallengeSuccessRate: 0.25, FrictionlessApprovalRate: 1, NonChallengedApprovalRate: 0.75,
+				ApprovalRateDiff: -0.5,
+			},
+		},
+		{
+			name: "challenge outperforms non-challenged",
+			transactions: []funnelfox.Transaction{
+				threeDSAttempt("stripe", challenged, "Y", true),
+				threeDSAttempt("stripe", nil, "", true),
+				threeDSAttempt("stripe", nil, "", false),
+			},
+			want: ThreeDSStats{
+				Attempts: 3, ThreeDS: 1, Challenged: 1, ChallengedApproved: 1, NonChallenged: 2, NonChallengedApproved: 1,
+				ChallengeRate: 1, ChallengeSuccessRate: 1, NonChallengedApprovalRate: 0.5,
+				ApprovalRateDiff: 0.5,
+			},
+		},
+		{
+			name: "no challenged attempts leaves diff empty",
+			transactions: []funnelfox.Transaction{
+				threeDSAttempt("stripe", frictionless, "Y", true),
+				threeDSAttempt("stripe", nil, "", false),
+			},
+			want: ThreeDSStats{
+				Attempts: 2, ThreeDS: 1, Frictionless: 1, FrictionlessApproved: 1, NonChallenged: 2, NonChallengedApproved: 1,
+				FrictionlessRate: 1, FrictionlessApprovalRate: 1, NonChallengedApprovalRate: 0.5,
+			},
+		},
+		{
+			name: "refunds and pending rows are skipped",
+			transactions: []funnelfox.Transaction{
+				threeDSAttempt("stripe", challenged, "C", false),
+				refunded,
+				{Status: "pending"},
+			},
+			want: ThreeDSStats{
+				Attempts: 1, ThreeDS: 1, Challenged: 1,
+				ChallengeRate: 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ThreeDS(slices.Values(tt.transactions))
+			got := r.Total
+			tt.want.Value = string(DimensionAll)
+			for _, f := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"challenge_rate", got.ChallengeRate, tt.want.ChallengeRate},
+				{"frictionless_rate", got.FrictionlessRate, tt.want.FrictionlessRate},
+				{"approval_rate_diff", got.ApprovalRateDiff, tt.want.ApprovalRateDiff},
+			} {
+				if math.Abs(f.got-f.want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+				}
+			}
+			got.ChallengeRate, got.FrictionlessRate, got.ApprovalRateDiff = tt.want.ChallengeRate, tt.want.FrictionlessRate, tt.want.ApprovalRateDiff
+			if got != tt.want {
+				t.Errorf("total = %+v\nwant    %+v", got, tt.want)
+			}
+			if s := r.Slices[DimensionPSP]; len(s) != 1 || s[0].Attempts != tt.want.Attempts {
+				t.Errorf("psp slices = %+v", s)
+			}
+		})
+	}
+}
+
+func TestThreeDSCodes(t *testing.T) {
+	challenged := ptr(true)
+	transactions := []funnelfox.Transaction{
+		threeDSAttempt("stripe", challenged, "C", true),
+		threeDSAttempt("stripe", challenged, "C", false),
+		threeDSAttempt("stripe", challenged, "N", false),
+		threeDSAttempt("stripe", nil, "", true),
+	}
+	r := ThreeDS(slices.Values(transactions))
+	want := []CodeCount{{Code: "C", Count: 2, Approved: 1}, {Code: "N", Count: 1}}
+	if !slices.Equal(r.ResponseCodes, want) {
+		t.Errorf("response codes = %+v, want %+v", r.ResponseCodes, want)
+	}
+	wantReasons := []CodeCount{{Code: unknownValue, Count: 3, Approved: 1}}
+	if !slices.Equal(r.ReasonCodes, wantReasons) {
+		t.Errorf("reason codes = %+v, want %+v", r.ReasonCodes, wantReasons)
+	}
+}
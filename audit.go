@@ -0,0 +1,167 @@
+package funnelfox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// mutatingEndpoints 会修改数据的 API，调用时写入审计记录
+var mutatingEndpoints = map[string]bool{
+	"/payment/refund":                 true,
+	"/checkout/one_click":             true,
+	"/subscription/enable_autorenew":  true,
+	"/subscription/disable_autorenew": true,
+	"/subscription/migration":         true,
+	"/discount":                       true,
+	"/subscription/defer":             true,
+	"/subscription/pause":             true,
+	"/subscription/resume":            true,
+	"/feature/create":                 true,
+	"/pp/create":                      true,
+}
+
+// auditRedactedFields 写入审计记录前需要脱敏的请求字段
+var auditRedactedFields = map[string]bool{
+	"client_metadata": true,
+}
+
+const auditRedacted = "[REDACTED]"
+
+// AuditRecord 审计记录
+type AuditRecord struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Actor     string         `json:"actor,omitempty"` // 操作人，来自 WithActor
+	OrgID     string         `json:"org_id"`
+	Endpoint  string         `json:"endpoint"`
+	Request   map[string]any `json:"request,omitempty"` // 脱敏后的请求体
+	Success   bool           `json:"success"`
+	Error     string         `json:"error,omitempty"`
+	ReqID     string         `json:"req_id,omitempty"`
+}
+
+// AuditSink 审计记录输出接口
+type AuditSink interface {
+	WriteAudit(record AuditRecord) error
+}
+
+type actorKey struct{}
+
+// WithActor 返回携带操作人信息的 ctx，配合 Client.WithContext 使用
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext 获取 ctx 中的操作人，没有时返回空字符串
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// SetAuditSink 设置审计记录输出，sink 为 nil 时关闭审计
+func (c *Client) SetAuditSink(sink AuditSink) {
+	c.audit = sink
+}
+
+// writeAudit 为修改类 API 调用写入审计记录，写入失败只记录日志
+func (c *Client) writeAudit(req *Request, resp *Response, ffErr *Error) {
+	if c.audit == nil || !mutatingEndpoints[req.Endpoint] {
+		return
+	}
+	record := AuditRecord{
+		Timestamp: time.Now().UTC(),
+		Actor:     ActorFromContext(req.Context),
+		OrgID:     c.orgID,
+		Endpoint:  req.Endpoint,
+		Request:   sanitizeAuditRequest(req.Body),
+		Success:   ffErr == nil,
+	}
+	if ffErr != nil {
+		record.Error = ffErr.Error()
+	}
+	if resp != nil {
+		record.ReqID = resp.ReqID
+	}
+	if err := c.audit.WriteAudit(record); err != nil {
+		c.logger.Error("funnelfox_audit_error",
+			String("endpoint", req.Endpoint),
+			ErrorField(err))
+	}
+}
+
+// sanitizeAuditRequest 将请求体转换为 map 并对敏感字段脱敏
+func sanitizeAuditRequest(body any) map[string]any {
+	if body == nil {
+		return nil
+	}
+	bs, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return nil
+	}
+	for k := range m {
+		if auditRedactedFields[k] {
+			m[k] = auditRedacted
+		}
+	}
+	return m
+}
+
+// JSONLAuditSink 以 JSON Lines 格式追加写入文件的审计输出
+type JSONLAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewJSONLAuditSink 打开（或创建）path 并以追加方式写入审计记录
+func NewJSONLAuditSink(path string) (*JSONLAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLAuditSink{file: f}, nil
+}
+
+func (s *JSONLAuditSink) WriteAudit(record AuditRecord) error {
+	bs, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	bs = append(bs, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(bs)
+	return err
+}
+
+// Close 关闭文件
+func (s *JSONLAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// MemoryAuditSink 内存审计输出，用于测试
+type MemoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (s *MemoryAuditSink) WriteAudit(record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+// Records 返回已写入的审计记录副本
+func (s *MemoryAuditSink) Records() []AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditRecord(nil), s.records...)
+}
@@ -0,0 +1,158 @@
+package funnelfox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	tests := []struct {
+		name        string
+		call        func(c *Client) *Error
+		status      int
+		body        string
+		wantRecord  bool
+		wantSuccess bool
+		wantReq     map[string]any
+	}{
+		{
+			name: "mutating success",
+			call: func(c *Client) *Error {
+				return c.Refund(RefundRequest{ExternalID: "u1", OrderID: "o1"})
+			},
+			status:      http.StatusOK,
+			body:        okResponse,
+			wantRecord:  true,
+			wantSuccess: true,
+			wantReq:     map[string]any{"external_id": "u1", "order_id": "o1"},
+		},
+		{
+			name: "mutating api error",
+			call: func(c *Client) *Error {
+				return c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"})
+			},
+			status:     http.StatusBadRequest,
+			body:       `{"status":"error","req_id":"req-1","error":[{"type":"invalid_request","msg":"bad"}]}`,
+			wantRecord: true,
+		},
+		{
+			name: "redacts client metadata",
+			call: func(c *Client) *Error {
+				_, err := c.OneClickPurchase(OneClickPurchaseRequest{
+					ExternalID:     "u1",
+					PPIdent:        "pp",
+					ClientMetadata: map[string]any{"ip": "127.0.0.1"},
+				})
+				return err
+			},
+			status:      http.StatusOK,
+			body:        okResponse,
+			wantRecord:  true,
+			wantSuccess: true,
+			wantReq:     map[string]any{"external_id": "u1", "pp_ident": "pp", "client_metadata": auditRedacted},
+		},
+		{
+			name: "read only",
+			call: func(c *Client) *Error {
+				_, err := c.GetPaymentsHistory(PaymentsHistoryRequest{ExternalID: "u1"})
+				return err
+			},
+			status: http.StatusOK,
+			body:   `{"status":"success","req_id":"req-1","data":{"payments":[]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &MemoryAuditSink{}
+			c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				return jsonResponse(tt.status, tt.body), nil
+			})
+			c.SetAuditSink(sink)
+			_ = tt.call(c.WithContext(WithActor(context.Background(), "alice")))
+
+			records := sink.Records()
+			if !tt.wantRecord {
+				if len(records) != 0 {
+					t.Fatalf("got %d records, want none", len(records))
+				}
+				return
+			}
+			if len(records) != 1 {
+				t.Fatalf("got %d records, want 1", len(records))
+			}
+			r := records[0]
+			if r.Actor != "alice" || r.OrgID != "org" || r.ReqID != "req-1" {
+				t.Errorf("record = %+v", r)
+			}
+			if r.Success != tt.wantSuccess || (r.Error == "") != tt.wantSuccess {
+				t.Errorf("success = %v, error = %q", r.Success, r.Error)
+			}
+			for k, v := range tt.wantReq {
+				if r.Request[k] != v {
+					t.Errorf("request[%s] = %v, want %v", k, r.Request[k], v)
+				}
+			}
+		})
+	}
+}
+
+type failingAuditSink struct{}
+
+func (failingAuditSink) WriteAudit(AuditRecord) error { return errors.New("disk full") }
+
+func TestAuditSinkErrorDoesNotFailRequest(t *testing.T) {
+	c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, okResponse), nil
+	})
+	c.SetAuditSink(failingAuditSink{})
+	if err := c.Refund(RefundRequest{ExternalID: "u1", OrderID: "o1"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONLAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i := 0; i < 2; i++ {
+		// 重新打开时追加而不是覆盖
+		sink, err := NewJSONLAuditSink(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.WriteAudit(AuditRecord{Endpoint: "/discount", Success: true}); err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("line %d: %v", lines, err)
+		}
+		if r.Endpoint != "/discount" || !r.Success {
+			t.Errorf("record = %+v", r)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("got %d lines, want 2", lines)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v", info.Mode().Perm())
+	}
+}
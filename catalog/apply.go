@@ -0,0 +1,50 @@
+package catalog
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// Apply 按计划创建缺失的 feature 和价格点，先创建 feature
+// 不会修改 unchanged 或 drifted 的条目。
+// API 无法列出 feature，Diff 会把未关联任何价格点的已有 feature 计划为 create，
+// 因此创建 feature 时 API 返回已存在视为成功
+func Apply(client *funnelfox.Client, c *Catalog, plan *Plan) error {
+	create := make(map[Kind]map[string]bool)
+	for _, ch := range plan.Changes {
+		if ch.Action != ActionCreate {
+			continue
+		}
+		if create[ch.Kind] == nil {
+			create[ch.Kind] = make(map[string]bool)
+		}
+		create[ch.Kind][ch.Ident] = true
+	}
+
+	for _, f := range c.Features {
+		if !create[KindFeature][f.Ident] {
+			continue
+		}
+		if _, err := client.CreateFeature(f); err != nil && !alreadyExists(err) {
+			return fmt.Errorf("create feature %q: %w", f.Ident, err)
+		}
+	}
+	for _, pp := range c.PricePoints {
+		if !create[KindPricePoint][pp.Ident] {
+			continue
+		}
+		if _, err := client.CreatePricePoint(pp); err != nil {
+			return fmt.Errorf("create price point %q: %w", pp.Ident, err)
+		}
+	}
+	return nil
+}
+
+// alreadyExists 判断创建失败是否因为条目已存在（HTTP 409 或错误信息包含 "already exists"）
+// 只匹配完整短语，"does not exist" 等其他错误仍按失败处理
+func alreadyExists(err *funnelfox.Error) bool {
+	return err.StatusCode == http.StatusConflict || strings.Contains(strings.ToLower(err.Message), "already exists")
+}
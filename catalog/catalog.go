@@ -0,0 +1,85 @@
+// Package catalog 以声明式文件（YAML/JSON）管理 feature 和价格点
+//
+// 价格点创建后不可修改，因此同步只会创建缺失的 feature 和价格点，
+// 已存在但与文件不一致的价格点会作为 drift 报告，不会被修改。
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byte-power/funnelfox"
+	"gopkg.in/yaml.v3"
+)
+
+// Catalog 声明式目录
+type Catalog struct {
+	Features    []funnelfox.FeatureCreateRequest    `json:"features"`
+	PricePoints []funnelfox.PricePointCreateRequest `json:"price_points"`
+}
+
+// Load 读取目录文件，根据扩展名选择 YAML（.yaml/.yml）或 JSON
+func Load(path string) (*Catalog, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(bs)
+	default:
+		return ParseJSON(bs)
+	}
+}
+
+// ParseJSON 解析 JSON 格式的目录
+func ParseJSON(data []byte) (*Catalog, error) {
+	var c Catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse catalog: %w", err)
+	}
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ParseYAML 解析 YAML 格式的目录，字段名与 JSON 相同
+func ParseYAML(data []byte) (*Catalog, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse catalog: %w", err)
+	}
+	bs, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parse catalog: %w", err)
+	}
+	return ParseJSON(bs)
+}
+
+// check 检查 ident 非空且不重复
+func (c *Catalog) check() error {
+	seen := make(map[string]bool)
+	for _, f := range c.Features {
+		if f.Ident == "" {
+			return fmt.Errorf("feature without ident")
+		}
+		if seen["feature:"+f.Ident] {
+			return fmt.Errorf("duplicate feature %q", f.Ident)
+		}
+		seen["feature:"+f.Ident] = true
+	}
+	for _, pp := range c.PricePoints {
+		if pp.Ident == "" {
+			return fmt.Errorf("price point without ident")
+		}
+		if seen["pp:"+pp.Ident] {
+			return fmt.Errorf("duplicate price point %q", pp.Ident)
+		}
+		seen["pp:"+pp.Ident] = true
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package catalog
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/byte-power/funnelfox"
+)
+
+const testCatalog = `
+features:
+  - ident: premium
+    feature_type: timebased
+  - ident: unattached
+    feature_type: timebased
+price_points:
+  - ident: monthly
+    currency_code: USD
+    next_price: 9.99
+    next_period: 1
+    next_period_duration: months
+    features: [premium]
+  - ident: yearly
+    currency_code: USD
+    next_price: 59.99
+    next_period: 1
+    next_period_duration: years
+    features: [premium]
+  - ident: weekly
+    currency_code: USD
+    next_price: 2.99
+    next_period: 1
+    next_period_duration: weeks
+`
+
+func existingPricePoints(t *testing.T, data string) []funnelfox.PricePoint {
+	t.Helper()
+	var pps []funnelfox.PricePoint
+	if err := json.Unmarshal([]byte(data), &pps); err != nil {
+		t.Fatal(err)
+	}
+	return pps
+}
+
+func TestDiff(t *testing.T) {
+	c, err := ParseYAML([]byte(testCatalog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := existingPricePoints(t, `[
+		{"ident":"monthly","currency":{"code":"USD"},"features":[{"ident":"premium"}],"intro_type":"no_intro",
+		 "next_price":"9.990","next_period":1,"next_period_duration":"months"},
+		{"ident":"yearly","currency":{"code":"EUR"},"features":[{"ident":"premium"}],"intro_type":"no_intro",
+		 "next_price":"49.99","next_period":1,"next_period_duration":"years"},
+		{"ident":"weekly","currency":{"code":"USD"},"intro_type":"free_trial",
+		 "next_price":"2.99","next_period":1,"next_period_duration":"weeks"}
+	]`)
+	plan := Diff(c, existing)
+
+	tests := []struct {
+		kind   Kind
+		ident  string
+		action Action
+		drift  []string
+	}{
+		{KindFeature, "premium", ActionUnchanged, nil},
+		{KindFeature, "unattached", ActionCreate, nil},
+		{KindPricePoint, "monthly", ActionUnchanged, nil},
+		{KindPricePoint, "yearly", ActionDrifted, []string{"currency_code", "next_price"}},
+		{KindPricePoint, "weekly", ActionDrifted, []string{"intro_type"}},
+	}
+	if len(plan.Changes) != len(tests) {
+		t.Fatalf("got %d changes, want %d", len(plan.Changes), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.ident, func(t *testing.T) {
+			ch := plan.Changes[i]
+			if ch.Kind != tt.kind || ch.Ident != tt.ident || ch.Action != tt.action {
+				t.Fatalf("change = %+v", ch)
+			}
+			var fields []string
+			for _, d := range ch.Drift {
+				fields = append(fields, d.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.drift, ",") {
+				t.Errorf("drift = %v, want %v", fields, tt.drift)
+			}
+		})
+	}
+	if !plan.HasDrift() || plan.Count(ActionCreate) != 1 {
+		t.Errorf("counts: create %d, drift %v", plan.Count(ActionCreate), plan.HasDrift())
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		featureResp func() *http.Response
+		wantErr     bool
+		wantCalls   []string
+	}{
+		{
+			name: "created",
+			featureResp: func() *http.Response {
+				return response(http.StatusOK, `{"status":"success","data":{}}`)
+			},
+			wantCalls: []string{"/feature/create unattached", "/pp/create weekly"},
+		},
+		{
+			name: "feature already exists",
+			featureResp: func() *http.Response {
+				return response(http.StatusBadRequest, `{"status":"error","error":[{"type":"invalid_request","msg":"feature already exists"}]}`)
+			},
+			wantCalls: []string{"/feature/create unattached", "/pp/create weekly"},
+		},
+		{
+			name: "feature conflict",
+			featureResp: func() *http.Response {
+				return response(http.StatusConflict, `{"status":"success"}`)
+			},
+			wantCalls: []string{"/feature/create unattached", "/pp/create weekly"},
+		},
+		{
+			name: "feature does not exist",
+			featureResp: func() *http.Response {
+				return response(http.StatusBadRequest, `{"status":"error","error":[{"type":"invalid_request","msg":"feature does not exist"}]}`)
+			},
+			wantErr:   true,
+			wantCalls: []string{"/feature/create unattached"},
+		},
+		{
+			name: "feature create fails",
+			featureResp: func() *http.Response {
+				return response(http.StatusBadRequest, `{"status":"error","error":[{"type":"invalid_request","msg":"bad feature_type"}]}`)
+			},
+			wantErr:   true,
+			wantCalls: []string{"/feature/create unattached"},
+		},
+	}
+	c, err := ParseYAML([]byte(testCatalog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			client := funnelfox.NewClientWithHTTPClient("org", "secret", &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				var body struct {
+					Ident string `json:"ident"`
+				}
+				bs, _ := io.ReadAll(req.Body)
+				_ = json.Unmarshal(bs, &body)
+				endpoint := strings.TrimPrefix(req.URL.Path, "/org/v1")
+				calls = append(calls, endpoint+" "+body.Ident)
+				if endpoint == "/feature/create" {
+					return tt.featureResp(), nil
+				}
+				return response(http.StatusOK, `{"status":"success","data":{}}`), nil
+			})}, nil)
+
+			plan := Diff(c, existingPricePoints(t, `[
+				{"ident":"monthly","currency":{"code":"USD"},"features":[{"ident":"premium"}]},
+				{"ident":"yearly","currency":{"code":"USD"},"features":[{"ident":"premium"}]}
+			]`))
+			err := Apply(client, c, plan)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(calls, ";") != strings.Join(tt.wantCalls, ";") {
+				t.Errorf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func response(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
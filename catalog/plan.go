@@ -0,0 +1,196 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// Kind 目录条目类型
+type Kind string
+
+const (
+	KindFeature    Kind = "feature"
+	KindPricePoint Kind = "price_point"
+)
+
+// Action 计划中的操作
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUnchanged Action = "unchanged"
+	ActionDrifted   Action = "drifted"
+)
+
+// FieldDrift 已存在价格点与目录不一致的字段
+type FieldDrift struct {
+	Field string `json:"field"`
+	Want  string `json:"want"`
+	Have  string `json:"have"`
+}
+
+// Change 单个条目的计划
+type Change struct {
+	Kind   Kind         `json:"kind"`
+	Ident  string       `json:"ident"`
+	Action Action       `json:"action"`
+	Drift  []FieldDrift `json:"drift,omitempty"`
+}
+
+// Plan 同步计划
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+// Diff 将目录与已存在的价格点比较并生成计划
+// 已存在的 feature 通过价格点上关联的 feature 推断，未关联价格点的 feature 计划为 create（见 Apply）
+func Diff(c *Catalog, existing []funnelfox.PricePoint) *Plan {
+	existingPP := make(map[string]funnelfox.PricePoint, len(existing))
+	existingFeatures := make(map[string]bool)
+	for _, pp := range existing {
+		existingPP[pp.Ident] = pp
+		for _, f := range pp.Features {
+			existingFeatures[f.Ident] = true
+		}
+	}
+
+	var plan Plan
+	for _, f := range c.Features {
+		action := ActionCreate
+		if existingFeatures[f.Ident] {
+			action = ActionUnchanged
+		}
+		plan.Changes = append(plan.Changes, Change{Kind: KindFeature, Ident: f.Ident, Action: action})
+	}
+	for _, want := range c.PricePoints {
+		have, ok := existingPP[want.Ident]
+		if !ok {
+			plan.Changes = append(plan.Changes, Change{Kind: KindPricePoint, Ident: want.Ident, Action: ActionCreate})
+			continue
+		}
+		drift := pricePointDrift(want, have)
+		action := ActionUnchanged
+		if len(drift) > 0 {
+			action = ActionDrifted
+		}
+		plan.Changes = append(plan.Changes, Change{Kind: KindPricePoint, Ident: want.Ident, Action: action, Drift: drift})
+	}
+	return &plan
+}
+
+// Count 返回指定操作的条目数
+func (p *Plan) Count(action Action) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// HasDrift 是否存在不一致的价格点
+func (p *Plan) HasDrift() bool {
+	return p.Count(ActionDrifted) > 0
+}
+
+// Print 以可读格式输出计划
+func (p *Plan) Print(w io.Writer) {
+	for _, c := range p.Changes {
+		fmt.Fprintf(w, "%-9s %-11s %s\n", c.Action, c.Kind, c.Ident)
+		for _, d := range c.Drift {
+			fmt.Fprintf(w, "          %s: want %s, have %s\n", d.Field, d.Want, d.Have)
+		}
+	}
+	fmt.Fprintf(w, "\n%d to create, %d unchanged, %d drifted\n",
+		p.Count(ActionCreate), p.Count(ActionUnchanged), p.Count(ActionDrifted))
+}
+
+// pricePointDrift 比较价格点的不可变字段
+func pricePointDrift(want funnelfox.PricePointCreateRequest, have funnelfox.PricePoint) []FieldDrift {
+	var drift []FieldDrift
+	add := func(field, w, h string) {
+		if w != h {
+			drift = append(drift, FieldDrift{Field: field, Want: w, Have: h})
+		}
+	}
+
+	add("currency_code", want.CurrencyCode, have.Currency.Code)
+	add("intro_type", introType(want.IntroType), introType(have.IntroType))
+	add("lifetime_price", formatPrice(want.LifetimePrice), normalizePrice(have.LifetimePrice))
+	add("intro_free_trial_period", formatInt(want.IntroFreeTrialPeriod), derefInt(have.IntroFreeTrialPeriod))
+	add("intro_free_trial_period_duration", string(want.IntroFreeTrialPeriodDuration), derefUnit(have.IntroFreeTrialPeriodDuration))
+	add("intro_paid_trial_price", formatPrice(want.IntroPaidTrialPrice), normalizePrice(have.IntroPaidTrialPrice))
+	add("intro_paid_trial_period", formatInt(want.IntroPaidTrialPeriod), derefInt(have.IntroPaidTrialPeriod))
+	add("intro_paid_trial_period_duration", string(want.IntroPaidTrialPeriodDuration), derefUnit(have.IntroPaidTrialPeriodDuration))
+	add("next_price", formatPrice(want.NextPrice), normalizePrice(have.NextPrice))
+	add("next_period", formatInt(want.NextPeriod), derefInt(have.NextPeriod))
+	add("next_period_duration", string(want.NextPeriodDuration), derefUnit(have.NextPeriodDuration))
+
+	haveFeatures := make([]string, 0, len(have.Features))
+	for _, f := range have.Features {
+		haveFeatures = append(haveFeatures, f.Ident)
+	}
+	add("features", joinSorted(want.Features), joinSorted(haveFeatures))
+	return drift
+}
+
+// introType 未设置的 intro_type 按 no_intro 比较
+func introType(t funnelfox.IntroType) string {
+	if t == "" {
+		return string(funnelfox.IntroTypeNoIntro)
+	}
+	return string(t)
+}
+
+// formatPrice 格式化价格，0 视为未设置
+func formatPrice(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// normalizePrice 规范化 API 返回的价格字符串，便于与 formatPrice 比较
+func normalizePrice(s *string) string {
+	if s == nil || *s == "" {
+		return ""
+	}
+	v, err := strconv.ParseFloat(*s, 64)
+	if err != nil {
+		return *s
+	}
+	return formatPrice(v)
+}
+
+func formatInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+func derefInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return formatInt(*v)
+}
+
+func derefUnit(v *funnelfox.PeriodDurationUnit) string {
+	if v == nil {
+		return ""
+	}
+	return string(*v)
+}
+
+func joinSorted(ss []string) string {
+	ss = append([]string(nil), ss...)
+	sort.Strings(ss)
+	return strings.Join(ss, ",")
+}
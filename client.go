@@ -2,12 +2,16 @@ package funnelfox
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var defaultFunnelFoxHTTPClient = &http.Client{
@@ -18,8 +22,19 @@ type Client struct {
 	httpClient *http.Client
 	baseURL    string
 	orgID      string
-	secretKey  string
+	secrets    SecretProvider
 	logger     Logger
+	metrics    MetricsRecorder
+	middleware []Middleware
+	audit      AuditSink
+	limiter    Limiter
+	ctx        context.Context
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	retryOnAuthFailure bool
+	actionGuard        bool
+	strictDecoding     bool
 }
 
 // NewClient 创建新的 FunnelFox 客户端
@@ -35,8 +50,9 @@ func NewClient(orgID, secretKey string, logger Logger) *Client {
 		httpClient: defaultFunnelFoxHTTPClient,
 		baseURL:    baseURL,
 		orgID:      orgID,
-		secretKey:  secretKey,
+		secrets:    StaticSecret(secretKey),
 		logger:     logger,
+		metrics:    &NopMetricsRecorder{},
 	}
 }
 
@@ -53,19 +69,118 @@ func NewClientWithHTTPClient(orgID, secretKey string, httpClient *http.Client, l
 		httpClient: httpClient,
 		baseURL:    baseURL,
 		orgID:      orgID,
-		secretKey:  secretKey,
+		secrets:    StaticSecret(secretKey),
 		logger:     logger,
+		metrics:    &NopMetricsRecorder{},
+	}
+}
+
+// WithContext 返回绑定了 ctx 的客户端浅拷贝，后续请求使用该 ctx（取消、超时、trace 传递）
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// doRequest 执行 API 调用：经过中间件链发送请求，并将响应数据解析到 response
+func (c *Client) doRequest(endpoint string, requestBody, response any, withSecretKey bool) (ffErr *Error) {
+	start := time.Now()
+	ctx, span := c.startRequestSpan(c.context(), endpoint, 0)
+	defer func() {
+		var err error
+		if ffErr != nil {
+			err = ffErr
+		}
+		c.metrics.ObserveRequest(endpoint, time.Since(start), err)
+		endSpan(span, err)
+	}()
+
+	req := &Request{
+		Context:       ctx,
+		Endpoint:      endpoint,
+		Body:          requestBody,
+		WithSecretKey: withSecretKey,
+		Header:        make(http.Header),
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	var apiResp *Response
+	ffErr = c.setSecretKeyHeader(req)
+	if ffErr == nil {
+		apiResp, ffErr = c.handler()(req)
+	}
+	if ffErr != nil && withSecretKey && c.retryOnAuthFailure && ffErr.isAuthFailure() {
+		used := req.Header.Get("ff-secret-key")
+		// 密钥没有变化时重试必然再次失败，只会多一次失败请求和审计记录
+		if key, err := c.refreshSecretKey(ctx); err != nil {
+			c.logger.Error("funnelfox_refresh_secret_key_error",
+				String("endpoint", endpoint),
+				ErrorField(err))
+		} else if key == used {
+			c.logger.Debug("funnelfox_secret_key_unchanged",
+				String("endpoint", endpoint))
+		} else {
+			c.logger.Info("funnelfox_retry_after_auth_failure",
+				String("endpoint", endpoint))
+			req.Header.Set("ff-secret-key", key)
+			span.SetAttributes(attrRetryAttempt.Int(1))
+			apiResp, ffErr = c.handler()(req)
+		}
+	}
+	c.writeAudit(req, apiResp, ffErr)
+	if apiResp != nil {
+		annotateResponseSpan(span, apiResp)
+		for _, e := range apiResp.Error {
+			c.metrics.IncAPIError(endpoint, e.Type)
+		}
+	}
+	if ffErr != nil {
+		return ffErr
 	}
+
+	// 解析响应数据
+	if response != nil && apiResp != nil && len(apiResp.Data) > 0 {
+		if err := json.Unmarshal(apiResp.Data, response); err != nil {
+			return WrapError(err, "failed to unmarshal response data")
+		}
+	}
+
+	return nil
+}
+
+// setSecretKeyHeader 需要密钥时从 SecretProvider 获取密钥写入 ff-secret-key 请求头
+func (c *Client) setSecretKeyHeader(r *Request) *Error {
+	if !r.WithSecretKey {
+		return nil
+	}
+	secretKey, err := c.secrets.SecretKey(r.Context)
+	if err != nil {
+		return WrapError(err, "failed to get secret key")
+	}
+	r.Header.Set("ff-secret-key", secretKey)
+	return nil
 }
 
-// doRequest 执行 HTTP POST 请求
-func (c *Client) doRequest(endpoint string, requestBody, response any, withSecretKey bool) *Error {
-	url := c.baseURL + endpoint
+// send 发送 HTTP POST 请求并解析响应信封，是中间件链的最内层 Handler
+// API 返回错误时同时返回解析后的 Response 和 *Error
+func (c *Client) send(r *Request) (*Response, *Error) {
+	ctx := r.Context
+	url := c.baseURL + r.Endpoint
 	var bodyReader io.Reader
-	if requestBody != nil {
-		bodyBytes, err := json.Marshal(requestBody)
+	if r.Body != nil {
+		bodyBytes, err := json.Marshal(r.Body)
 		if err != nil {
-			return WrapError(err, "failed to marshal request body")
+			return nil, WrapError(err, "failed to marshal request body")
 		}
 		bodyReader = bytes.NewReader(bodyBytes)
 		c.logger.Debug("funnelfox_request",
@@ -73,15 +188,19 @@ func (c *Client) doRequest(endpoint string, requestBody, response any, withSecre
 			String("body", string(bodyBytes)))
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bodyReader)
 	if err != nil {
-		return WrapError(err, "failed to create request")
+		return nil, WrapError(err, "failed to create request")
+	}
+	for k, v := range r.Header {
+		req.Header[k] = v
 	}
+	c.injectTraceContext(ctx, req.Header)
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	if withSecretKey {
-		req.Header.Set("ff-secret-key", c.secretKey)
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, WrapError(err, "rate limiter wait failed")
+		}
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -89,7 +208,7 @@ func (c *Client) doRequest(endpoint string, requestBody, response any, withSecre
 		c.logger.Error("funnelfox_request_error",
 			String("url", url),
 			ErrorField(err))
-		return WrapError(err, "request failed")
+		return nil, WrapError(err, "request failed")
 	}
 	defer resp.Body.Close()
 
@@ -98,18 +217,19 @@ func (c *Client) doRequest(endpoint string, requestBody, response any, withSecre
 		c.logger.Error("funnelfox_read_response_error",
 			String("url", url),
 			ErrorField(err))
-		return WrapError(err, "failed to read response")
+		return nil, WrapError(err, "failed to read response")
 	}
 
 	c.logger.Debug("funnelfox_response",
 		String("url", url),
 		Number("status_code", resp.StatusCode),
 		String("body", string(respBody)))
+	trace.SpanFromContext(ctx).SetAttributes(attrHTTPStatusCode.Int(resp.StatusCode))
 
 	// 解析响应
 	var apiResp Response
 	if err := json.Unmarshal(respBody, &apiResp); err != nil {
-		return WrapError(err, "failed to unmarshal response")
+		return nil, WrapError(err, "failed to unmarshal response").withStatusCode(resp.StatusCode)
 	}
 
 	// 检查响应状态
@@ -128,22 +248,15 @@ func (c *Client) doRequest(endpoint string, requestBody, response any, withSecre
 			String("url", url),
 			String("req_id", apiResp.ReqID),
 			String("error", errMsg))
-		return WrapErrorf(nil, "FunnelFox API error: %s (req_id: %s)", errMsg, apiResp.ReqID)
+		return &apiResp, WrapErrorf(nil, "FunnelFox API error: %s (req_id: %s)", errMsg, apiResp.ReqID).withStatusCode(resp.StatusCode)
 	}
 
 	// 如果响应状态码不是 200-299，返回错误
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return WrapErrorf(nil, "HTTP %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	// 解析响应数据
-	if response != nil && len(apiResp.Data) > 0 {
-		if err := json.Unmarshal(apiResp.Data, response); err != nil {
-			return WrapError(err, "failed to unmarshal response data")
-		}
+		return &apiResp, WrapErrorf(nil, "HTTP %d: %s", resp.StatusCode, string(respBody)).withStatusCode(resp.StatusCode)
 	}
 
-	return nil
+	return &apiResp, nil
 }
 
 // ===== Payment Management =====
@@ -169,16 +282,25 @@ func (c *Client) OneClickPurchase(req OneClickPurchaseRequest) (*OneClickPurchas
 
 // EnableAutoRenew 启用自动续费
 func (c *Client) EnableAutoRenew(req EnableAutoRenewRequest) *Error {
+	if err := c.checkAction(req.ExternalID, req.SubsID, ActionEnableAutorenew); err != nil {
+		return err
+	}
 	return c.doRequest("/subscription/enable_autorenew", req, nil, true)
 }
 
 // DisableAutoRenew 禁用自动续费
 func (c *Client) DisableAutoRenew(req DisableAutoRenewRequest) *Error {
+	if err := c.checkAction(req.ExternalID, req.SubsID, ActionDisableAutorenew); err != nil {
+		return err
+	}
 	return c.doRequest("/subscription/disable_autorenew", req, nil, true)
 }
 
 // SubscriptionMigration 迁移订阅到另一个价格点
 func (c *Client) SubscriptionMigration(req SubscriptionMigrationRequest) (*SubscriptionMigrationResponse, *Error) {
+	if err := c.checkAction(req.ExternalID, req.SubsID, ActionMigrate); err != nil {
+		return nil, err
+	}
 	var resp SubscriptionMigrationResponse
 	if err := c.doRequest("/subscription/migration", req, &resp, true); err != nil {
 		return nil, err
@@ -188,21 +310,42 @@ func (c *Client) SubscriptionMigration(req SubscriptionMigrationRequest) (*Subsc
 
 // ApplyDiscount 应用百分比折扣
 func (c *Client) ApplyDiscount(req DiscountRequest) *Error {
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+	if err := c.checkAction(req.ExternalID, req.SubsID, ActionDiscount); err != nil {
+		return err
+	}
 	return c.doRequest("/discount", req, nil, true)
 }
 
 // DeferSubscription 延迟订阅的下次扣费时间
 func (c *Client) DeferSubscription(req SubscriptionDeferRequest) *Error {
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+	if err := c.checkAction(req.ExternalID, req.SubsID, ActionDefer); err != nil {
+		return err
+	}
 	return c.doRequest("/subscription/defer", req, nil, true)
 }
 
 // PauseSubscription 暂停订阅
 func (c *Client) PauseSubscription(req SubscriptionPauseRequest) *Error {
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+	if err := c.checkAction(req.ExternalID, req.SubsID, ActionPause); err != nil {
+		return err
+	}
 	return c.doRequest("/subscription/pause", req, nil, true)
 }
 
 // ResumeSubscription 恢复订阅
 func (c *Client) ResumeSubscription(req SubscriptionResumeRequest) *Error {
+	if err := c.checkAction(req.ExternalID, req.SubsID, ActionResume); err != nil {
+		return err
+	}
 	return c.doRequest("/subscription/resume", req, nil, true)
 }
 
@@ -214,11 +357,19 @@ func (c *Client) ListPricePoints(req PricePointsListRequest) (*PricePointsListRe
 	if err := c.doRequest("/price_points", req, &resp, false); err != nil {
 		return nil, err
 	}
+	unknown := make(unknownFields)
+	for _, pp := range resp.PricePoints {
+		unknown.addPricePoint("price_points.", pp)
+	}
+	c.logUnknownFields("/price_points", unknown)
 	return &resp, nil
 }
 
 // CreateFeature 创建 feature
 func (c *Client) CreateFeature(req FeatureCreateRequest) (*FeatureCreateResponse, *Error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
 	var resp FeatureCreateResponse
 	if err := c.doRequest("/feature/create", req, &resp, true); err != nil {
 		return nil, err
@@ -228,6 +379,9 @@ func (c *Client) CreateFeature(req FeatureCreateRequest) (*FeatureCreateResponse
 
 // CreatePricePoint 创建 price point
 func (c *Client) CreatePricePoint(req PricePointCreateRequest) (*PricePointCreateResponse, *Error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
 	var resp PricePointCreateResponse
 	if err := c.doRequest("/pp/create", req, &resp, true); err != nil {
 		return nil, err
@@ -239,27 +393,56 @@ func (c *Client) CreatePricePoint(req PricePointCreateRequest) (*PricePointCreat
 
 // GetMyAssets 获取用户资产（订阅和一次性购买）
 func (c *Client) GetMyAssets(req MyAssetsRequest) (*MyAssetsResponse, *Error) {
-	var resp rawMyAssetsResponse
-	if err := c.doRequest("/my_assets", req, &resp, false); err != nil {
+	var res MyAssetsResponse
+	if err := c.doRequest("/my_assets", req, &res, false); err != nil {
 		return nil, err
 	}
-	return resp.toMyAssetsResponse(), nil
+	if err := c.checkDecode("/my_assets", res.timeErrors()); err != nil {
+		return nil, err
+	}
+	unknown := make(unknownFields)
+	for i := range res.Subscriptions {
+		c.logUnknownSubscriptionValues(res.Subscriptions[i].SubscriptionField)
+		unknown.addSubscription("subscriptions.", &res.Subscriptions[i])
+	}
+	for i := range res.OneOffPurchases {
+		unknown.addOneoff("oneoffs.", &res.OneOffPurchases[i])
+	}
+	c.logUnknownFields("/my_assets", unknown)
+	return &res, nil
 }
 
 // GetPaymentsHistory 获取支付历史
 func (c *Client) GetPaymentsHistory(req PaymentsHistoryRequest) (*PaymentsHistoryResponse, *Error) {
-	var resp rawPaymentsHistoryResponse
-	if err := c.doRequest("/payments_history", req, &resp, true); err != nil {
+	var res PaymentsHistoryResponse
+	if err := c.doRequest("/payments_history", req, &res, true); err != nil {
+		return nil, err
+	}
+	if err := c.checkDecode("/payments_history", res.timeErrors()); err != nil {
 		return nil, err
 	}
-	return resp.toPaymentsHistoryResponse(), nil
+	unknown := make(unknownFields)
+	for _, p := range res.Payments {
+		unknown.add("payments.", p.Extra)
+		unknown.add("payments.currency.", p.Currency.Extra)
+	}
+	c.logUnknownFields("/payments_history", unknown)
+	return &res, nil
 }
 
 // GetTransactionReport 获取所有交易
 func (c *Client) GetTransactionReport(req TransactionReportRequest) (*TransactionReportResponse, *Error) {
-	var resp rawTransactionReportResponse
-	if err := c.doRequest("/transaction_report", req, &resp, true); err != nil {
+	var res TransactionReportResponse
+	if err := c.doRequest("/transaction_report", req, &res, true); err != nil {
 		return nil, err
 	}
-	return resp.toTransactionReportResponse(), nil
+	if err := c.checkDecode("/transaction_report", res.timeErrors()); err != nil {
+		return nil, err
+	}
+	unknown := make(unknownFields)
+	for _, t := range res.Transactions {
+		unknown.add("transactions.", t.Extra)
+	}
+	c.logUnknownFields("/transaction_report", unknown)
+	return &res, nil
 }
@@ -0,0 +1,72 @@
+package funnelfox
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// roundTripFunc 将函数适配为 http.RoundTripper，测试中代替真实的 API
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// jsonResponse 构造状态码为 status、响应体为 body 的响应
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// newTestClient 创建请求交给 rt 处理的客户端
+func newTestClient(t *testing.T, rt roundTripFunc) *Client {
+	t.Helper()
+	return NewClientWithHTTPClient("org", "secret", &http.Client{Transport: rt}, nil)
+}
+
+const okResponse = `{"status":"success","req_id":"req-1","data":{}}`
+
+// recordingLogger 记录日志消息的 Logger
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]any
+}
+
+func (l *recordingLogger) log(level, msg string, fields []Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m := make(map[string]any, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, fields: m})
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...Field) { l.log("debug", msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...Field)  { l.log("info", msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...Field) { l.log("error", msg, fields) }
+
+// messages 返回非 debug 级别的日志消息
+func (l *recordingLogger) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var msgs []string
+	for _, e := range l.entries {
+		if e.level != "debug" {
+			msgs = append(msgs, e.msg)
+		}
+	}
+	return msgs
+}
@@ -0,0 +1,60 @@
+// ffcatalog 将声明式目录文件与 FunnelFox 价格点同步
+//
+// 用法：
+//
+//	FUNNELFOX_SECRET_KEY=... ffcatalog -org <orgID> -file catalog.yaml [-apply]
+//
+// 默认只输出计划；指定 -apply 时创建缺失的 feature 和价格点。
+// 存在 drift 时以退出码 2 结束。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/byte-power/funnelfox"
+	"github.com/byte-power/funnelfox/catalog"
+)
+
+func main() {
+	orgID := flag.String("org", "", "FunnelFox organization ID")
+	secretEnv := flag.String("secret-env", "FUNNELFOX_SECRET_KEY", "environment variable holding the secret key")
+	file := flag.String("file", "catalog.yaml", "catalog file (.yaml, .yml or .json)")
+	apply := flag.Bool("apply", false, "create missing features and price points")
+	flag.Parse()
+
+	if *orgID == "" {
+		fmt.Fprintln(os.Stderr, "-org is required")
+		os.Exit(1)
+	}
+
+	c, err := catalog.Load(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	client := funnelfox.NewClient(*orgID, "", nil)
+	client.SetSecretProvider(funnelfox.EnvSecret(*secretEnv))
+
+	existing, ffErr := client.ListPricePoints(funnelfox.PricePointsListRequest{})
+	if ffErr != nil {
+		fmt.Fprintln(os.Stderr, ffErr)
+		os.Exit(1)
+	}
+
+	plan := catalog.Diff(c, existing.PricePoints)
+	plan.Print(os.Stdout)
+
+	if *apply {
+		if err := catalog.Apply(client, c, plan); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("applied %d creations\n", plan.Count(catalog.ActionCreate))
+	}
+	if plan.HasDrift() {
+		os.Exit(2)
+	}
+}
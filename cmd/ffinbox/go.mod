@@ -0,0 +1,32 @@
+module github.com/byte-power/funnelfox/cmd/ffinbox
+
+go 1.23.0
+
+require (
+	github.com/byte-power/funnelfox v0.0.0-00010101000000-000000000000
+	github.com/byte-power/funnelfox/inbox/sqlitestore v0.0.0-00010101000000-000000000000
+	modernc.org/sqlite v1.34.5
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel v1.38.0 // indirect
+	go.opentelemetry.io/otel/metric v1.38.0 // indirect
+	go.opentelemetry.io/otel/trace v1.38.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+)
+
+replace (
+	github.com/byte-power/funnelfox => ../..
+	github.com/byte-power/funnelfox/inbox/sqlitestore => ../../inbox/sqlitestore
+)
@@ -0,0 +1,151 @@
+// ffinbox 查看和重放 webhook 收件箱中的死信，支持文件存储和 SQLite 存储
+//
+// 用法：
+//
+//	ffinbox -dir <inbox dir> list
+//	ffinbox -dir <inbox dir> show <id>
+//	ffinbox -dir <inbox dir> replay <id> [<id> ...]
+//	ffinbox -dir <inbox dir> replay -all
+//	ffinbox -store sqlite -dsn <sqlite dsn> list
+//
+// 重放的消息回到待处理队列，尝试次数清零，由运行中的 worker 重新处理。
+// 作为独立模块发布，SQLite 驱动依赖不会进入 SDK 的 go.mod。
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/byte-power/funnelfox/inbox"
+	"github.com/byte-power/funnelfox/inbox/sqlitestore"
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	kind := flag.String("store", "file", "store type: file or sqlite")
+	dir := flag.String("dir", "", "inbox directory used by inbox.NewFileStore (-store file)")
+	dsn := flag.String("dsn", "", "SQLite data source name used by sqlitestore.New (-store sqlite)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ffinbox (-dir <inbox dir> | -store sqlite -dsn <dsn>) list | show <id> | replay (-all | <id>...)")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	store, closeStore, err := openStore(ctx, *kind, *dir, *dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(1)
+	}
+	defer closeStore()
+
+	switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+	case "list":
+		err = list(ctx, store)
+	case "show":
+		err = show(ctx, store, args)
+	case "replay":
+		err = replay(ctx, store, args)
+	default:
+		flag.Usage()
+		closeStore()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		closeStore()
+		os.Exit(1)
+	}
+}
+
+// openStore 按 -store 打开存储，返回的 close 函数用于释放数据库连接
+func openStore(ctx context.Context, kind, dir, dsn string) (inbox.Store, func(), error) {
+	switch kind {
+	case "file":
+		if dir == "" {
+			return nil, nil, fmt.Errorf("-dir is required for the file store")
+		}
+		store, err := inbox.NewFileStore(dir)
+		return store, func() {}, err
+	case "sqlite":
+		if dsn == "" {
+			return nil, nil, fmt.Errorf("-dsn is required for the sqlite store")
+		}
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		store, err := sqlitestore.New(ctx, db)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return store, func() { db.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown store %q, want file or sqlite", kind)
+	}
+}
+
+func list(ctx context.Context, store inbox.Store) error {
+	msgs, err := store.DeadLetters(ctx)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		fmt.Printf("%s\t%s\tattempts=%d\t%s\n",
+			msg.ID, msg.ReceivedAt.Format(time.RFC3339), msg.Attempts, msg.LastError)
+	}
+	fmt.Printf("%d dead letters\n", len(msgs))
+	return nil
+}
+
+func show(ctx context.Context, store inbox.Store, ids []string) error {
+	if len(ids) != 1 {
+		return fmt.Errorf("show requires exactly one message id")
+	}
+	msgs, err := store.DeadLetters(ctx)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if msg.ID == ids[0] {
+			fmt.Printf("id: %s\nreceived_at: %s\nattempts: %d\nlast_error: %s\n\n%s\n",
+				msg.ID, msg.ReceivedAt.Format(time.RFC3339), msg.Attempts, msg.LastError, msg.Body)
+			return nil
+		}
+	}
+	return inbox.ErrNotFound
+}
+
+func replay(ctx context.Context, store inbox.Store, ids []string) error {
+	if len(ids) == 1 && ids[0] == "-all" {
+		msgs, err := store.DeadLetters(ctx)
+		if err != nil {
+			return err
+		}
+		ids = ids[:0]
+		for _, msg := range msgs {
+			ids = append(ids, msg.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("replay requires -all or at least one message id")
+	}
+	for _, id := range ids {
+		if err := store.Replay(ctx, id); err != nil {
+			return fmt.Errorf("replay %s: %w", id, err)
+		}
+		fmt.Printf("replayed %s\n", id)
+	}
+	return nil
+}
@@ -2,12 +2,14 @@ package funnelfox
 
 import (
 	"fmt"
+	"net/http"
 )
 
 // Error SDK 错误类型
 type Error struct {
-	Message string
-	Err     error
+	Message    string
+	Err        error
+	StatusCode int // HTTP 状态码，请求未得到响应时为 0
 }
 
 func (e *Error) Error() string {
@@ -21,6 +23,16 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+func (e *Error) withStatusCode(code int) *Error {
+	e.StatusCode = code
+	return e
+}
+
+// isAuthFailure 是否为鉴权失败
+func (e *Error) isAuthFailure() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
 // NewError 创建新错误
 func NewError(message string) *Error {
 	return &Error{Message: message}
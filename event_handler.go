@@ -0,0 +1,134 @@
+package funnelfox
+
+import "context"
+
+// EventHandler 按事件类型和子类型拆分的 webhook 处理接口
+// 嵌入 BaseEventHandler 后只需实现关心的方法；通过 DispatchEvent 路由事件
+type EventHandler interface {
+	// 订阅事件
+	OnTrialStarted(ctx context.Context, e *SubscriptionEvent) error
+	OnConverted(ctx context.Context, e *SubscriptionEvent) error
+	OnRenewed(ctx context.Context, e *SubscriptionEvent) error
+	OnUnsubscribed(ctx context.Context, e *SubscriptionEvent) error
+	OnPaused(ctx context.Context, e *SubscriptionEvent) error
+	OnDeferred(ctx context.Context, e *SubscriptionEvent) error
+	OnResumed(ctx context.Context, e *SubscriptionEvent) error
+	OnAutorenewRecovered(ctx context.Context, e *SubscriptionEvent) error
+	OnExpired(ctx context.Context, e *SubscriptionEvent) error
+	OnSubscriptionUnknown(ctx context.Context, e *SubscriptionEvent) error
+	OnGraceStarted(ctx context.Context, e *SubscriptionEvent) error
+	OnRetryStarted(ctx context.Context, e *SubscriptionEvent) error
+	OnGraceFinished(ctx context.Context, e *SubscriptionEvent) error
+	OnRecovered(ctx context.Context, e *SubscriptionEvent) error
+	OnPostponedSubscriptionPlanned(ctx context.Context, e *SubscriptionEvent) error
+
+	// 订单事件
+	OnOrderSettled(ctx context.Context, e *OrderEvent) error
+	OnOrderDeclined(ctx context.Context, e *OrderEvent) error
+
+	// 一次性购买事件
+	OnOneoffGranted(ctx context.Context, e *OneoffEvent) error
+	OnOneoffRevoked(ctx context.Context, e *OneoffEvent) error
+
+	// 退款事件
+	OnRefundSettled(ctx context.Context, e *RefundEvent) error
+
+	// OnUnhandled 未知事件类型或子类型，以及缺少对应内容的事件
+	OnUnhandled(ctx context.Context, e *Event) error
+}
+
+// BaseEventHandler EventHandler 的空实现，所有方法直接返回 nil
+type BaseEventHandler struct{}
+
+var _ EventHandler = BaseEventHandler{}
+
+func (BaseEventHandler) OnTrialStarted(ctx context.Context, e *SubscriptionEvent) error { return nil }
+func (BaseEventHandler) OnConverted(ctx context.Context, e *SubscriptionEvent) error    { return nil }
+func (BaseEventHandler) OnRenewed(ctx context.Context, e *SubscriptionEvent) error      { return nil }
+func (BaseEventHandler) OnUnsubscribed(ctx context.Context, e *SubscriptionEvent) error { return nil }
+func (BaseEventHandler) OnPaused(ctx context.Context, e *SubscriptionEvent) error       { return nil }
+func (BaseEventHandler) OnDeferred(ctx context.Context, e *SubscriptionEvent) error     { return nil }
+func (BaseEventHandler) OnResumed(ctx context.Context, e *SubscriptionEvent) error      { return nil }
+func (BaseEventHandler) OnAutorenewRecovered(ctx context.Context, e *SubscriptionEvent) error {
+	return nil
+}
+func (BaseEventHandler) OnExpired(ctx context.Context, e *SubscriptionEvent) error { return nil }
+func (BaseEventHandler) OnSubscriptionUnknown(ctx context.Context, e *SubscriptionEvent) error {
+	return nil
+}
+func (BaseEventHandler) OnGraceStarted(ctx context.Context, e *SubscriptionEvent) error  { return nil }
+func (BaseEventHandler) OnRetryStarted(ctx context.Context, e *SubscriptionEvent) error  { return nil }
+func (BaseEventHandler) OnGraceFinished(ctx context.Context, e *SubscriptionEvent) error { return nil }
+func (BaseEventHandler) OnRecovered(ctx context.Context, e *SubscriptionEvent) error     { return nil }
+func (BaseEventHandler) OnPostponedSubscriptionPlanned(ctx context.Context, e *SubscriptionEvent) error {
+	return nil
+}
+func (BaseEventHandler) OnOrderSettled(ctx context.Context, e *OrderEvent) error   { return nil }
+func (BaseEventHandler) OnOrderDeclined(ctx context.Context, e *OrderEvent) error  { return nil }
+func (BaseEventHandler) OnOneoffGranted(ctx context.Context, e *OneoffEvent) error { return nil }
+func (BaseEventHandler) OnOneoffRevoked(ctx context.Context, e *OneoffEvent) error { return nil }
+func (BaseEventHandler) OnRefundSettled(ctx context.Context, e *RefundEvent) error { return nil }
+func (BaseEventHandler) OnUnhandled(ctx context.Context, e *Event) error           { return nil }
+
+// DispatchEvent 按事件类型和子类型调用 h 的对应方法，无法识别的事件交给 OnUnhandled
+//
+//	client.HandleEvent(body, func(ctx context.Context, e *funnelfox.Event) error {
+//		return funnelfox.DispatchEvent(ctx, e, handler)
+//	})
+func DispatchEvent(ctx context.Context, event *Event, h EventHandler) error {
+	switch p := event.Payload().(type) {
+	case *SubscriptionEvent:
+		switch p.Subtype {
+		case SubscriptionSubtypeStartingTrial:
+			return h.OnTrialStarted(ctx, p)
+		case SubscriptionSubtypeConversion:
+			return h.OnConverted(ctx, p)
+		case SubscriptionSubtypeRenewing:
+			return h.OnRenewed(ctx, p)
+		case SubscriptionSubtypeUnsubscription:
+			return h.OnUnsubscribed(ctx, p)
+		case SubscriptionSubtypePausing:
+			return h.OnPaused(ctx, p)
+		case SubscriptionSubtypeDeferring:
+			return h.OnDeferred(ctx, p)
+		case SubscriptionSubtypeResuming:
+			return h.OnResumed(ctx, p)
+		case SubscriptionSubtypeRecoveringAutorenew:
+			return h.OnAutorenewRecovered(ctx, p)
+		case SubscriptionSubtypeExpiration:
+			return h.OnExpired(ctx, p)
+		case SubscriptionSubtypeUnknown:
+			return h.OnSubscriptionUnknown(ctx, p)
+		case SubscriptionSubtypeStartGrace:
+			return h.OnGraceStarted(ctx, p)
+		case SubscriptionSubtypeStartRetry:
+			return h.OnRetryStarted(ctx, p)
+		case SubscriptionSubtypeFinishGrace:
+			return h.OnGraceFinished(ctx, p)
+		case SubscriptionSubtypeRecovering:
+			return h.OnRecovered(ctx, p)
+		case SubscriptionSubtypePlanningPostponedSubscription:
+			return h.OnPostponedSubscriptionPlanned(ctx, p)
+		}
+	case *OrderEvent:
+		switch p.Subtype {
+		case OrderSubtypeSettled:
+			return h.OnOrderSettled(ctx, p)
+		case OrderSubtypeDeclined:
+			return h.OnOrderDeclined(ctx, p)
+		}
+	case *OneoffEvent:
+		switch p.Subtype {
+		case OneoffSubtypeGranted:
+			return h.OnOneoffGranted(ctx, p)
+		case OneoffSubtypeRevoked:
+			return h.OnOneoffRevoked(ctx, p)
+		}
+	case *RefundEvent:
+		switch p.Subtype {
+		case RefundSubtypeSettled:
+			return h.OnRefundSettled(ctx, p)
+		}
+	}
+	return h.OnUnhandled(ctx, event)
+}
@@ -0,0 +1,141 @@
+package funnelfox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// callRecorder 记录被调用的方法名，未覆盖的方法走 BaseEventHandler
+type callRecorder struct {
+	BaseEventHandler
+	calls []string
+	err   error
+}
+
+func (r *callRecorder) record(name string) error {
+	r.calls = append(r.calls, name)
+	return r.err
+}
+
+func (r *callRecorder) OnTrialStarted(ctx context.Context, e *SubscriptionEvent) error {
+	return r.record("OnTrialStarted")
+}
+
+func (r *callRecorder) OnRenewed(ctx context.Context, e *SubscriptionEvent) error {
+	return r.record("OnRenewed")
+}
+
+func (r *callRecorder) OnUnsubscribed(ctx context.Context, e *SubscriptionEvent) error {
+	return r.record("OnUnsubscribed")
+}
+
+func (r *callRecorder) OnPaused(ctx context.Context, e *SubscriptionEvent) error {
+	return r.record("OnPaused")
+}
+
+func (r *callRecorder) OnExpired(ctx context.Context, e *SubscriptionEvent) error {
+	return r.record("OnExpired")
+}
+
+func (r *callRecorder) OnGraceStarted(ctx context.Context, e *SubscriptionEvent) error {
+	return r.record("OnGraceStarted")
+}
+
+func (r *callRecorder) OnOrderSettled(ctx context.Context, e *OrderEvent) error {
+	return r.record("OnOrderSettled")
+}
+
+func (r *callRecorder) OnOrderDeclined(ctx context.Context, e *OrderEvent) error {
+	return r.record("OnOrderDeclined")
+}
+
+func (r *callRecorder) OnOneoffGranted(ctx context.Context, e *OneoffEvent) error {
+	return r.record("OnOneoffGranted")
+}
+
+func (r *callRecorder) OnRefundSettled(ctx context.Context, e *RefundEvent) error {
+	return r.record("OnRefundSettled")
+}
+
+func (r *callRecorder) OnUnhandled(ctx context.Context, e *Event) error {
+	return r.record("OnUnhandled")
+}
+
+func eventJSON(eventType, subtype, body string) string {
+	s := fmt.Sprintf(`{"event_id":"e1","event_timestamp":"2024-05-01T10:00:00Z","event_type":%q,"subtype":%q,"user":{"email":"","external_id":"u1"}`, eventType, subtype)
+	if body != "" {
+		s += "," + body
+	}
+	return s + "}"
+}
+
+func TestDispatchEvent(t *testing.T) {
+	const (
+		sub    = `"subscription":{"id":"s1"}`
+		order  = `"order":{"order_id":"o1"}`
+		oneoff = `"oneoff":{"id":"p1"}`
+	)
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"trial started", eventJSON("subscription", "starting_trial", sub), "OnTrialStarted"},
+		{"renewed", eventJSON("subscription", "renewing", sub), "OnRenewed"},
+		{"unsubscribed", eventJSON("subscription", "unsubscription", sub), "OnUnsubscribed"},
+		{"paused", eventJSON("subscription", "pausing", sub), "OnPaused"},
+		{"expired", eventJSON("subscription", "expiration", sub), "OnExpired"},
+		{"grace started", eventJSON("subscription", "start_grace", sub), "OnGraceStarted"},
+		{"order settled", eventJSON("order", "settled", order), "OnOrderSettled"},
+		{"order declined", eventJSON("order", "declined", order), "OnOrderDeclined"},
+		{"oneoff granted", eventJSON("oneoff", "granted", oneoff), "OnOneoffGranted"},
+		{"refund settled", flatRefundEvent, "OnRefundSettled"},
+		{"unknown subscription subtype", eventJSON("subscription", "teleported", sub), "OnUnhandled"},
+		{"unknown order subtype", eventJSON("order", "pending", order), "OnUnhandled"},
+		{"unknown event type", eventJSON("chargeback", "opened", ""), "OnUnhandled"},
+		{"missing payload", eventJSON("subscription", "renewing", ""), "OnUnhandled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ParseEvent([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseEvent: %v", err)
+			}
+			var r callRecorder
+			if err := DispatchEvent(context.Background(), event, &r); err != nil {
+				t.Fatalf("DispatchEvent: %v", err)
+			}
+			assertStrings(t, "calls", r.calls, []string{tt.want})
+		})
+	}
+}
+
+func TestDispatchEventReturnsHandlerError(t *testing.T) {
+	event, err := ParseEvent([]byte(eventJSON("order", "declined", `"order":{"order_id":"o1"}`)))
+	if err != nil {
+		t.Fatalf("ParseEvent: %v", err)
+	}
+	want := errors.New("boom")
+	r := callRecorder{err: want}
+	if err := DispatchEvent(context.Background(), event, &r); !errors.Is(err, want) {
+		t.Errorf("DispatchEvent error = %v, want %v", err, want)
+	}
+}
+
+func TestBaseEventHandlerIgnoresEvents(t *testing.T) {
+	for _, data := range []string{
+		eventJSON("subscription", "convertion", `"subscription":{"id":"s1"}`),
+		eventJSON("oneoff", "revoked", `"oneoff":{"id":"p1"}`),
+		eventJSON("chargeback", "opened", ""),
+	} {
+		event, err := ParseEvent([]byte(data))
+		if err != nil {
+			t.Fatalf("ParseEvent: %v", err)
+		}
+		if err := DispatchEvent(context.Background(), event, BaseEventHandler{}); err != nil {
+			t.Errorf("DispatchEvent(%s) = %v, want nil", event.Subtype, err)
+		}
+	}
+}
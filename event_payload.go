@@ -0,0 +1,250 @@
+package funnelfox
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// eventSubtypes 各事件类型 SDK 已定义的子类型，子类型的取值只在 model.go 的 EventSubtype 常量中定义，
+// 下面各类型的子类型常量和 IsKnown 都由此派生
+var eventSubtypes = map[EventType][]EventSubtype{
+	EventTypeSubscription: {
+		EventSubtypeSubscriptionStartingTrial,
+		EventSubtypeSubscriptionConversion,
+		EventSubtypeSubscriptionRenewing,
+		EventSubtypeSubscriptionUnsubscription,
+		EventSubtypeSubscriptionPausing,
+		EventSubtypeSubscriptionDeferring,
+		EventSubtypeSubscriptionResuming,
+		EventSubtypeSubscriptionRecoveringAutorenew,
+		EventSubtypeSubscriptionExpiration,
+		EventSubtypeSubscriptionUnknown,
+		EventSubtypeSubscriptionStartGrace,
+		EventSubtypeSubscriptionStartRetry,
+		EventSubtypeSubscriptionFinishGrace,
+		EventSubtypeSubscriptionRecovering,
+		EventSubtypeSubscriptionPlanningPostponedSubscription,
+	},
+	EventTypeOrder:  {EventSubtypeOrderSettled, EventSubtypeOrderDeclined},
+	EventTypeOneoff: {EventSubtypeOneoffGranted, EventSubtypeOneoffRevoked},
+	EventTypeRefund: {EventSubtypeRefundSettled},
+}
+
+func isKnownSubtype(eventType EventType, subtype EventSubtype) bool {
+	return slices.Contains(eventSubtypes[eventType], subtype)
+}
+
+// SubscriptionSubtype 订阅事件子类型
+type SubscriptionSubtype string
+
+const (
+	SubscriptionSubtypeStartingTrial                 = SubscriptionSubtype(EventSubtypeSubscriptionStartingTrial)
+	SubscriptionSubtypeConversion                    = SubscriptionSubtype(EventSubtypeSubscriptionConversion)
+	SubscriptionSubtypeRenewing                      = SubscriptionSubtype(EventSubtypeSubscriptionRenewing)
+	SubscriptionSubtypeUnsubscription                = SubscriptionSubtype(EventSubtypeSubscriptionUnsubscription)
+	SubscriptionSubtypePausing                       = SubscriptionSubtype(EventSubtypeSubscriptionPausing)
+	SubscriptionSubtypeDeferring                     = SubscriptionSubtype(EventSubtypeSubscriptionDeferring)
+	SubscriptionSubtypeResuming                      = SubscriptionSubtype(EventSubtypeSubscriptionResuming)
+	SubscriptionSubtypeRecoveringAutorenew           = SubscriptionSubtype(EventSubtypeSubscriptionRecoveringAutorenew)
+	SubscriptionSubtypeExpiration                    = SubscriptionSubtype(EventSubtypeSubscriptionExpiration)
+	SubscriptionSubtypeUnknown                       = SubscriptionSubtype(EventSubtypeSubscriptionUnknown)
+	SubscriptionSubtypeStartGrace                    = SubscriptionSubtype(EventSubtypeSubscriptionStartGrace)
+	SubscriptionSubtypeStartRetry                    = SubscriptionSubtype(EventSubtypeSubscriptionStartRetry)
+	SubscriptionSubtypeFinishGrace                   = SubscriptionSubtype(EventSubtypeSubscriptionFinishGrace)
+	SubscriptionSubtypeRecovering                    = SubscriptionSubtype(EventSubtypeSubscriptionRecovering)
+	SubscriptionSubtypePlanningPostponedSubscription = SubscriptionSubtype(EventSubtypeSubscriptionPlanningPostponedSubscription)
+)
+
+// IsKnown 是否为 SDK 已定义的子类型
+func (s SubscriptionSubtype) IsKnown() bool {
+	return isKnownSubtype(EventTypeSubscription, EventSubtype(s))
+}
+
+// OrderSubtype 订单事件子类型
+type OrderSubtype string
+
+const (
+	OrderSubtypeSettled  = OrderSubtype(EventSubtypeOrderSettled)
+	OrderSubtypeDeclined = OrderSubtype(EventSubtypeOrderDeclined)
+)
+
+// IsKnown 是否为 SDK 已定义的子类型
+func (s OrderSubtype) IsKnown() bool {
+	return isKnownSubtype(EventTypeOrder, EventSubtype(s))
+}
+
+// OneoffSubtype 一次性购买事件子类型
+type OneoffSubtype string
+
+const (
+	OneoffSubtypeGranted = OneoffSubtype(EventSubtypeOneoffGranted)
+	OneoffSubtypeRevoked = OneoffSubtype(EventSubtypeOneoffRevoked)
+)
+
+// IsKnown 是否为 SDK 已定义的子类型
+func (s OneoffSubtype) IsKnown() bool {
+	return isKnownSubtype(EventTypeOneoff, EventSubtype(s))
+}
+
+// RefundSubtype 退款事件子类型
+type RefundSubtype string
+
+const (
+	RefundSubtypeSettled = RefundSubtype(EventSubtypeRefundSettled)
+)
+
+// IsKnown 是否为 SDK 已定义的子类型
+func (s RefundSubtype) IsKnown() bool {
+	return isKnownSubtype(EventTypeRefund, EventSubtype(s))
+}
+
+// EventUser 事件中的用户信息
+type EventUser struct {
+	Email      string `json:"email"`
+	ExternalID string `json:"external_id"`
+}
+
+// EventHeader 所有事件共有的字段
+type EventHeader struct {
+	EventID        string
+	EventTimestamp time.Time
+	ExternalID     *string
+	IsLivemode     *bool
+	User           EventUser
+}
+
+// SubscriptionEvent 订阅事件
+type SubscriptionEvent struct {
+	EventHeader
+	Subtype      SubscriptionSubtype
+	Subscription Subscription
+}
+
+// OrderEvent 订单事件
+type OrderEvent struct {
+	EventHeader
+	Subtype OrderSubtype
+	Order   Order
+}
+
+// OneoffEvent 一次性购买事件
+type OneoffEvent struct {
+	EventHeader
+	Subtype OneoffSubtype
+	Oneoff  OneOffPurchase
+}
+
+// RefundEvent 退款事件
+type RefundEvent struct {
+	EventHeader
+	Subtype RefundSubtype
+	Refund  RefundInfo
+	Order   *Order // 事件中携带订单信息时非 nil
+}
+
+func (e *Event) header() EventHeader {
+	return EventHeader{
+		EventID:        e.EventID,
+		EventTimestamp: e.EventTimestamp,
+		ExternalID:     e.ExternalID,
+		IsLivemode:     e.IsLivemode,
+		User:           EventUser(e.User),
+	}
+}
+
+// AsSubscription 事件为订阅事件时返回其类型化内容
+func (e *Event) AsSubscription() (*SubscriptionEvent, bool) {
+	if e.EventType != EventTypeSubscription || e.Subscription == nil {
+		return nil, false
+	}
+	return &SubscriptionEvent{
+		EventHeader:  e.header(),
+		Subtype:      SubscriptionSubtype(e.Subtype),
+		Subscription: *e.Subscription,
+	}, true
+}
+
+// AsOrder 事件为订单事件时返回其类型化内容
+func (e *Event) AsOrder() (*OrderEvent, bool) {
+	if e.EventType != EventTypeOrder || e.Order == nil {
+		return nil, false
+	}
+	return &OrderEvent{
+		EventHeader: e.header(),
+		Subtype:     OrderSubtype(e.Subtype),
+		Order:       *e.Order,
+	}, true
+}
+
+// AsOneoff 事件为一次性购买事件时返回其类型化内容
+func (e *Event) AsOneoff() (*OneoffEvent, bool) {
+	if e.EventType != EventTypeOneoff || e.Oneoff == nil {
+		return nil, false
+	}
+	return &OneoffEvent{
+		EventHeader: e.header(),
+		Subtype:     OneoffSubtype(e.Subtype),
+		Oneoff:      *e.Oneoff,
+	}, true
+}
+
+// AsRefund 事件为退款事件时返回其类型化内容
+func (e *Event) AsRefund() (*RefundEvent, bool) {
+	if e.EventType != EventTypeRefund || e.RefundInfo == nil {
+		return nil, false
+	}
+	return &RefundEvent{
+		EventHeader: e.header(),
+		Subtype:     RefundSubtype(e.Subtype),
+		Refund:      *e.RefundInfo,
+		Order:       e.Order,
+	}, true
+}
+
+// Payload 返回类型化的事件内容，用于 type switch：
+//
+//	switch p := event.Payload().(type) {
+//	case *funnelfox.SubscriptionEvent:
+//	case *funnelfox.OrderEvent:
+//	case *funnelfox.OneoffEvent:
+//	case *funnelfox.RefundEvent:
+//	}
+//
+// 未知事件类型或缺少对应内容时返回 nil
+func (e *Event) Payload() any {
+	if p, ok := e.AsSubscription(); ok {
+		return p
+	}
+	if p, ok := e.AsOrder(); ok {
+		return p
+	}
+	if p, ok := e.AsOneoff(); ok {
+		return p
+	}
+	if p, ok := e.AsRefund(); ok {
+		return p
+	}
+	return nil
+}
+
+// decodeRefundInfo 解析退款事件中的退款信息：优先使用嵌套的 refund 对象，否则使用顶层字段
+func decodeRefundInfo(obj *jsonObject, topLevel *RawRefundInfo, d *timeDecoder) (*RefundInfo, error) {
+	raw := topLevel
+	prefix := ""
+	if nested, ok := obj.values["refund"]; ok {
+		var r RawRefundInfo
+		if err := json.Unmarshal(nested, &r); err != nil {
+			return nil, err
+		}
+		raw = &r
+		prefix = "refund."
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	return &RefundInfo{
+		RefundInfoField: raw.RefundInfoField,
+		CreatedAt:       d.parse(prefix+"created_at", raw.CreatedAt),
+	}, nil
+}
@@ -0,0 +1,168 @@
+package funnelfox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const (
+	flatRefundEvent     = `{"event_id":"r1","event_timestamp":"2024-05-01T10:00:00Z","event_type":"refund","subtype":"settled","amount_refunded":"9.99","order_id":"o9","trx_id":"t9","currency_code":"USD","created_at":"2024-05-01T09:00:00Z","user":{"email":"","external_id":"u1"}}`
+	nestedRefundEvent   = `{"event_id":"r2","event_timestamp":"2024-05-01T10:00:00Z","event_type":"refund","subtype":"settled","order_id":"o9","refund":{"amount_refunded":"4.50","order_id":"o9","trx_id":"t10","currency_code":"EUR","created_at":"2024-05-01T09:00:00Z","reason":"duplicate"},"user":{"email":"","external_id":"u1"}}`
+	orderWithRefundKeys = `{"event_id":"e3","event_timestamp":"2024-05-01T10:00:00Z","event_type":"order","subtype":"settled","order_id":"o1","currency_code":"USD","order":{"order_id":"o1"}}`
+)
+
+func TestRefundEventShapes(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantRefund *RefundInfoField
+		wantExtra  []string
+	}{
+		{
+			name:       "flat",
+			data:       flatRefundEvent,
+			wantRefund: &RefundInfoField{AmountRefunded: "9.99", OrderID: "o9", TrxID: "t9", CurrencyCode: "USD"},
+		},
+		{
+			name:       "nested",
+			data:       nestedRefundEvent,
+			wantRefund: &RefundInfoField{AmountRefunded: "4.50", OrderID: "o9", TrxID: "t10", CurrencyCode: "EUR"},
+			wantExtra:  []string{"order_id", "refund"},
+		},
+		{
+			name:      "non-refund event",
+			data:      orderWithRefundKeys,
+			wantExtra: []string{"currency_code", "order_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e Event
+			out := roundTrip(t, []byte(tt.data), &e)
+			if string(out) != tt.data {
+				t.Errorf("round trip mismatch\n got: %s\nwant: %s", out, tt.data)
+			}
+			assertStrings(t, "extra", e.Extra.Keys(), tt.wantExtra)
+
+			refund, ok := e.AsRefund()
+			if (tt.wantRefund != nil) != ok {
+				t.Fatalf("AsRefund() ok = %v", ok)
+			}
+			if ok && refund.Refund.RefundInfoField != *tt.wantRefund {
+				t.Errorf("refund = %+v, want %+v", refund.Refund.RefundInfoField, *tt.wantRefund)
+			}
+			if ok && refund.Refund.CreatedAt == nil {
+				t.Error("created_at not parsed")
+			}
+		})
+	}
+}
+
+func TestRefundEventEdits(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		old  string
+		new  string
+	}{
+		{
+			name: "flat",
+			data: flatRefundEvent,
+			old:  `"amount_refunded":"9.99"`,
+			new:  `"amount_refunded":"1.00"`,
+		},
+		{
+			name: "nested keeps top-level order_id",
+			data: nestedRefundEvent,
+			old:  `"refund":{"amount_refunded":"4.50"`,
+			new:  `"refund":{"amount_refunded":"1.00"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e Event
+			if err := json.Unmarshal([]byte(tt.data), &e); err != nil {
+				t.Fatal(err)
+			}
+			e.RefundInfo.AmountRefunded = "1.00"
+			out, err := json.Marshal(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := strings.Replace(tt.data, tt.old, tt.new, 1)
+			if string(out) != want {
+				t.Errorf("got:  %s\nwant: %s", out, want)
+			}
+
+			// 再次解析得到修改后的退款信息
+			var again Event
+			if err := json.Unmarshal(out, &again); err != nil {
+				t.Fatal(err)
+			}
+			if again.RefundInfo == nil || again.RefundInfo.AmountRefunded != "1.00" {
+				t.Errorf("refund after re-parse = %+v", again.RefundInfo)
+			}
+		})
+	}
+}
+
+func TestPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"subscription", `{"event_id":"e","event_timestamp":"2024-05-01T10:00:00Z","event_type":"subscription","subtype":"renewing","subscription":{"subs_id":"s1"}}`, "*funnelfox.SubscriptionEvent"},
+		{"order", orderWithRefundKeys, "*funnelfox.OrderEvent"},
+		{"oneoff", `{"event_id":"e","event_timestamp":"2024-05-01T10:00:00Z","event_type":"oneoff","subtype":"granted","oneoff":{"oneoff_id":"x"}}`, "*funnelfox.OneoffEvent"},
+		{"refund", nestedRefundEvent, "*funnelfox.RefundEvent"},
+		{"order without content", `{"event_id":"e","event_timestamp":"2024-05-01T10:00:00Z","event_type":"order","subtype":"settled"}`, "<nil>"},
+		{"unknown type", `{"event_id":"e","event_timestamp":"2024-05-01T10:00:00Z","event_type":"chargeback"}`, "<nil>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := ParseEvent([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprintf("%T", e.Payload()); got != tt.want {
+				t.Errorf("Payload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtypeIsKnown(t *testing.T) {
+	isKnown := map[EventType]func(EventSubtype) bool{
+		EventTypeSubscription: func(s EventSubtype) bool { return SubscriptionSubtype(s).IsKnown() },
+		EventTypeOrder:        func(s EventSubtype) bool { return OrderSubtype(s).IsKnown() },
+		EventTypeOneoff:       func(s EventSubtype) bool { return OneoffSubtype(s).IsKnown() },
+		EventTypeRefund:       func(s EventSubtype) bool { return RefundSubtype(s).IsKnown() },
+	}
+	for eventType, subtypes := range eventSubtypes {
+		for _, s := range subtypes {
+			if !isKnown[eventType](s) {
+				t.Errorf("%s subtype %s is not known", eventType, s)
+			}
+		}
+	}
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"subscription renewing", SubscriptionSubtypeRenewing.IsKnown(), true},
+		{"order granted", OrderSubtype(EventSubtypeOneoffGranted).IsKnown(), false},
+		{"oneoff settled", OneoffSubtype(EventSubtypeOrderSettled).IsKnown(), false},
+		{"refund declined", RefundSubtype(EventSubtypeOrderDeclined).IsKnown(), false},
+		{"new subscription subtype", SubscriptionSubtype("upgrading").IsKnown(), false},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: IsKnown() = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
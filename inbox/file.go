@@ -0,0 +1,239 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore 文件存储，每条消息保存为目录中的一个 JSON 文件
+//
+//	<dir>/pending/<id>.json
+//	<dir>/dead/<id>.json
+//	<dir>/acked/<id>.json（已确认记录，只保存 ID 和确认时间）
+//
+// 写入使用临时文件加重命名保证原子性；同一目录只应被一个进程使用
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+var _ Store = (*FileStore)(nil)
+
+const (
+	pendingDir = "pending"
+	deadDir    = "dead"
+	ackedDir   = "acked"
+)
+
+// tombstone 已确认记录
+type tombstone struct {
+	ID      string    `json:"id"`
+	AckedAt time.Time `json:"acked_at"`
+}
+
+// NewFileStore 创建文件存储，目录不存在时自动创建
+func NewFileStore(dir string) (*FileStore, error) {
+	for _, sub := range []string{pendingDir, deadDir, ackedDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, err
+		}
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(sub, id string) string {
+	// 转义 ID，避免路径分隔符等字符
+	return filepath.Join(s.dir, sub, url.PathEscape(id)+".json")
+}
+
+func (s *FileStore) Put(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range []string{pendingDir, deadDir, ackedDir} {
+		if _, err := os.Stat(s.path(sub, msg.ID)); err == nil {
+			return nil
+		}
+	}
+	return s.write(pendingDir, msg)
+}
+
+func (s *FileStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.list(pendingDir)
+	if err != nil {
+		return nil, err
+	}
+	var due []Message
+	for _, msg := range all {
+		if !msg.NextAttemptAt.After(now) {
+			due = append(due, msg)
+		}
+	}
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].NextAttemptAt = now.Add(lease)
+		if err := s.write(pendingDir, due[i]); err != nil {
+			return nil, err
+		}
+	}
+	return due, nil
+}
+
+func (s *FileStore) Ack(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(s.path(pendingDir, id)); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := s.writeJSON(s.path(ackedDir, id), tombstone{ID: id, AckedAt: at}); err != nil {
+		return err
+	}
+	return os.Remove(s.path(pendingDir, id))
+}
+
+func (s *FileStore) Retry(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(s.path(pendingDir, msg.ID)); err != nil {
+		return ErrNotFound
+	}
+	return s.write(pendingDir, msg)
+}
+
+func (s *FileStore) DeadLetter(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.write(deadDir, msg); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(pendingDir, msg.ID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileStore) DeadLetters(ctx context.Context) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list(deadDir)
+}
+
+func (s *FileStore) Replay(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, err := s.read(s.path(deadDir, id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.write(pendingDir, resetForReplay(msg)); err != nil {
+		return err
+	}
+	return os.Remove(s.path(deadDir, id))
+}
+
+func (s *FileStore) Purge(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := filepath.Join(s.dir, ackedDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		bs, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		var t tombstone
+		if err := json.Unmarshal(bs, &t); err != nil {
+			return err
+		}
+		if t.AckedAt.Before(before) {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) write(sub string, msg Message) error {
+	return s.writeJSON(s.path(sub, msg.ID), msg)
+}
+
+// writeJSON 以临时文件加重命名的方式原子写入 v
+func (s *FileStore) writeJSON(target string, v any) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(bs); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *FileStore) read(path string) (Message, error) {
+	var msg Message
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return msg, err
+	}
+	err = json.Unmarshal(bs, &msg)
+	return msg, err
+}
+
+// list 读取目录中的所有消息，按接收时间排序
+func (s *FileStore) list(sub string) ([]Message, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, sub))
+	if err != nil {
+		return nil, err
+	}
+	var msgs []Message
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		msg, err := s.read(filepath.Join(s.dir, sub, name))
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	sortByReceived(msgs)
+	return msgs, nil
+}
@@ -0,0 +1,414 @@
+package inbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// 默认配置
+const (
+	DefaultWorkers      = 4
+	DefaultMaxAttempts  = 10
+	DefaultPollInterval = time.Second
+	DefaultLease        = 5 * time.Minute
+	DefaultDedupTTL     = 72 * time.Hour
+	purgeInterval       = 10 * time.Minute
+	maxBodySize         = 1 << 20
+	maxRunErrors        = 100
+)
+
+// ErrUnauthorized 请求校验失败
+var ErrUnauthorized = errors.New("inbox: unauthorized webhook request")
+
+// ErrHandlerPanic 事件处理函数 panic，按一次失败的尝试重试
+var ErrHandlerPanic = errors.New("inbox: handler panicked")
+
+// DeadLetterError 消息处理失败并已转入死信
+type DeadLetterError struct {
+	ID       string
+	Attempts int
+	Err      error
+}
+
+func (e *DeadLetterError) Error() string {
+	return fmt.Sprintf("inbox: message %s dead-lettered after %d attempts: %v", e.ID, e.Attempts, e.Err)
+}
+
+func (e *DeadLetterError) Unwrap() error {
+	return e.Err
+}
+
+// Verifier 校验 webhook 请求，返回错误时请求被拒绝且不会保存
+type Verifier func(r *http.Request, body []byte) error
+
+// HeaderSecret 校验请求头 header 的值与 secret 一致
+func HeaderSecret(header, secret string) Verifier {
+	return func(r *http.Request, body []byte) error {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(header)), []byte(secret)) != 1 {
+			return ErrUnauthorized
+		}
+		return nil
+	}
+}
+
+// Backoff 返回第 attempt 次（从 1 开始）失败后到下次重试的等待时长
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff 指数退避：base, 2*base, 4*base ... 最大为 max
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt; i++ {
+			d *= 2
+			if d >= max {
+				return max
+			}
+		}
+		return d
+	}
+}
+
+// Inbox webhook 收件箱
+//
+// 作为 http.Handler 使用时只校验并保存请求体；Run 启动的 worker 负责解析和处理事件。
+// Set 开头的配置方法需在 Run 之前调用
+type Inbox struct {
+	store        Store
+	client       *funnelfox.Client
+	handle       func(ctx context.Context, event *funnelfox.Event) error
+	logger       funnelfox.Logger
+	verifier     Verifier
+	workers      int
+	maxAttempts  int
+	backoff      Backoff
+	pollInterval time.Duration
+	lease        time.Duration
+	dedupTTL     time.Duration
+	wake         chan struct{}
+}
+
+// New 创建收件箱
+// client: 用于解析事件（tracing、指标、严格解码等配置随 client 生效），可以为 nil（使用 funnelfox.ParseEvent）
+// handle: 事件处理函数，返回错误或 panic 时消息按退避策略重试
+// logger: 日志记录器，可以为 nil（使用 NopLogger）
+func New(store Store, client *funnelfox.Client, handle func(ctx context.Context, event *funnelfox.Event) error, logger funnelfox.Logger) *Inbox {
+	if logger == nil {
+		logger = &funnelfox.NopLogger{}
+	}
+	return &Inbox{
+		store:        store,
+		client:       client,
+		handle:       handle,
+		logger:       logger,
+		workers:      DefaultWorkers,
+		maxAttempts:  DefaultMaxAttempts,
+		backoff:      ExponentialBackoff(time.Second, time.Hour),
+		pollInterval: DefaultPollInterval,
+		lease:        DefaultLease,
+		dedupTTL:     DefaultDedupTTL,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// SetVerifier 设置请求校验，默认不校验
+func (i *Inbox) SetVerifier(v Verifier) {
+	i.verifier = v
+}
+
+// SetWorkers 设置并发处理的 worker 数量
+func (i *Inbox) SetWorkers(n int) {
+	if n > 0 {
+		i.workers = n
+	}
+}
+
+// SetMaxAttempts 设置最大尝试次数，超过后消息转入死信
+func (i *Inbox) SetMaxAttempts(n int) {
+	if n > 0 {
+		i.maxAttempts = n
+	}
+}
+
+// SetBackoff 设置重试退避策略
+func (i *Inbox) SetBackoff(b Backoff) {
+	if b != nil {
+		i.backoff = b
+	}
+}
+
+// SetPollInterval 设置空闲时轮询存储的间隔
+func (i *Inbox) SetPollInterval(d time.Duration) {
+	if d > 0 {
+		i.pollInterval = d
+	}
+}
+
+// SetLease 设置消息被领取后的租约时长，处理超过该时长未完成的消息会被重新领取
+func (i *Inbox) SetLease(d time.Duration) {
+	if d > 0 {
+		i.lease = d
+	}
+}
+
+// SetDedupTTL 设置处理成功的消息ID的保留时长，期间相同ID的重复投递被忽略，默认 72 小时
+func (i *Inbox) SetDedupTTL(d time.Duration) {
+	if d > 0 {
+		i.dedupTTL = d
+	}
+}
+
+// ServeHTTP 校验请求并保存原始请求体，保存成功后立即返回 200；请求体超过 1MB 时返回 413
+func (i *Inbox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			i.logger.Error("funnelfox_inbox_body_too_large",
+				funnelfox.String("path", r.URL.Path),
+				funnelfox.Number("limit", int(tooLarge.Limit)))
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if i.verifier != nil {
+		if err := i.verifier(r, body); err != nil {
+			i.logger.Error("funnelfox_inbox_unauthorized",
+				funnelfox.String("path", r.URL.Path),
+				funnelfox.ErrorField(err))
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	id, err := messageID(body)
+	if err != nil {
+		i.logger.Error("funnelfox_inbox_invalid_body", funnelfox.ErrorField(err))
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	msg := Message{ID: id, Body: body, ReceivedAt: now, NextAttemptAt: now}
+	if err := i.store.Put(r.Context(), msg); err != nil {
+		i.logger.Error("funnelfox_inbox_put_error",
+			funnelfox.String("message_id", id),
+			funnelfox.ErrorField(err))
+		http.Error(w, "failed to store event", http.StatusInternalServerError)
+		return
+	}
+	i.logger.Debug("funnelfox_inbox_received", funnelfox.String("message_id", id))
+	i.notify()
+	w.WriteHeader(http.StatusOK)
+}
+
+// messageID 使用事件的 event_id 作为消息ID，缺失时使用请求体的 SHA-256
+func messageID(body []byte) (string, error) {
+	var payload struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	if payload.EventID != "" {
+		return payload.EventID, nil
+	}
+	sum := sha256.Sum256(body)
+	return "sha256-" + hex.EncodeToString(sum[:]), nil
+}
+
+// notify 唤醒空闲的 worker
+func (i *Inbox) notify() {
+	select {
+	case i.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run 启动 worker 处理消息，阻塞直到 ctx 结束且所有 worker 退出
+//
+// 返回运行期间的存储错误和转入死信的 *DeadLetterError（最多保留 100 个），全部成功时返回 nil
+func (i *Inbox) Run(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		errs runErrors
+	)
+	for n := 0; n < i.workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			i.work(ctx, &errs)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		i.purge(ctx, &errs)
+	}()
+	wg.Wait()
+	return errs.err()
+}
+
+// purge 定期删除超过 dedupTTL 的已确认记录
+func (i *Inbox) purge(ctx context.Context, errs *runErrors) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		if err := i.store.Purge(ctx, time.Now().UTC().Add(-i.dedupTTL)); err != nil && ctx.Err() == nil {
+			i.logger.Error("funnelfox_inbox_store_error", funnelfox.ErrorField(err))
+			errs.add(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runErrors 汇总 worker 的错误，超过 maxRunErrors 后只计数
+type runErrors struct {
+	mu      sync.Mutex
+	errs    []error
+	omitted int
+}
+
+func (r *runErrors) add(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errs) < maxRunErrors {
+		r.errs = append(r.errs, err)
+	} else {
+		r.omitted++
+	}
+}
+
+func (r *runErrors) err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := r.errs
+	if r.omitted > 0 {
+		errs = append(errs, fmt.Errorf("inbox: %d more errors omitted", r.omitted))
+	}
+	return errors.Join(errs...)
+}
+
+func (i *Inbox) work(ctx context.Context, errs *runErrors) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-i.wake:
+		}
+		for ctx.Err() == nil {
+			processed, err := i.ProcessOne(ctx)
+			if err != nil {
+				errs.add(err)
+				var dead *DeadLetterError
+				if !errors.As(err, &dead) {
+					i.logger.Error("funnelfox_inbox_store_error", funnelfox.ErrorField(err))
+					break
+				}
+			}
+			if !processed {
+				break
+			}
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(i.pollInterval)
+	}
+}
+
+// ProcessOne 领取并处理一条到期消息，没有到期消息时返回 false
+// 消息转入死信时返回 *DeadLetterError，其余错误来自存储
+func (i *Inbox) ProcessOne(ctx context.Context) (bool, error) {
+	msgs, err := i.store.Claim(ctx, time.Now().UTC(), i.lease, 1)
+	if err != nil || len(msgs) == 0 {
+		return false, err
+	}
+	return true, i.process(ctx, msgs[0])
+}
+
+func (i *Inbox) process(ctx context.Context, msg Message) error {
+	msg.Attempts++
+	event, err := i.handleEvent(ctx, msg.Body)
+	if err == nil {
+		i.logger.Debug("funnelfox_inbox_processed",
+			funnelfox.String("message_id", msg.ID),
+			funnelfox.Number("attempts", msg.Attempts))
+		return i.store.Ack(ctx, msg.ID, time.Now().UTC())
+	}
+
+	msg.LastError = err.Error()
+	// 解析失败重试也不会成功，直接转入死信
+	if event == nil || msg.Attempts >= i.maxAttempts {
+		i.logger.Error("funnelfox_inbox_dead_letter",
+			funnelfox.String("message_id", msg.ID),
+			funnelfox.Number("attempts", msg.Attempts),
+			funnelfox.ErrorField(err))
+		if err := i.store.DeadLetter(ctx, msg); err != nil {
+			return err
+		}
+		return &DeadLetterError{ID: msg.ID, Attempts: msg.Attempts, Err: err}
+	}
+	msg.NextAttemptAt = time.Now().UTC().Add(i.backoff(msg.Attempts))
+	i.logger.Info("funnelfox_inbox_retry",
+		funnelfox.String("message_id", msg.ID),
+		funnelfox.Number("attempts", msg.Attempts),
+		funnelfox.String("next_attempt_at", msg.NextAttemptAt.Format(time.RFC3339)),
+		funnelfox.ErrorField(err))
+	return i.store.Retry(ctx, msg)
+}
+
+// handleEvent 解析并处理事件，解析失败时返回的 event 为 nil
+func (i *Inbox) handleEvent(ctx context.Context, body []byte) (*funnelfox.Event, error) {
+	var handle func(ctx context.Context, event *funnelfox.Event) error
+	if i.handle != nil {
+		handle = i.safeHandle
+	}
+	if i.client != nil {
+		return i.client.WithContext(ctx).HandleEvent(body, handle)
+	}
+	event, err := funnelfox.ParseEvent(body)
+	if err != nil {
+		return nil, err
+	}
+	if handle != nil {
+		err = handle(ctx, event)
+	}
+	return event, err
+}
+
+// safeHandle 调用处理函数，panic 转为包装了 ErrHandlerPanic 的错误，避免一个事件导致 worker 退出
+func (i *Inbox) safeHandle(ctx context.Context, event *funnelfox.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+			i.logger.Error("funnelfox_inbox_handler_panic",
+				funnelfox.String("event_id", event.EventID),
+				funnelfox.String("stack", string(debug.Stack())),
+				funnelfox.ErrorField(err))
+		}
+	}()
+	return i.handle(ctx, event)
+}
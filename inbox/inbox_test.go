@@ -0,0 +1,274 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byte-power/funnelfox"
+)
+
+var testNow = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func testMessage(id string, received time.Duration) Message {
+	at := testNow.Add(received)
+	return Message{ID: id, Body: []byte(`{"event_id":"` + id + `"}`), ReceivedAt: at, NextAttemptAt: at}
+}
+
+func messageIDs(msgs []Message) []string {
+	ids := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, name string, got []Message, want ...string) {
+	t.Helper()
+	if ids := messageIDs(got); !slices.Equal(ids, want) && (len(ids) != 0 || len(want) != 0) {
+		t.Fatalf("%s = %v, want %v", name, ids, want)
+	}
+}
+
+func mustPut(t *testing.T, s Store, msgs ...Message) {
+	t.Helper()
+	for _, msg := range msgs {
+		if err := s.Put(context.Background(), msg); err != nil {
+			t.Fatalf("Put(%s): %v", msg.ID, err)
+		}
+	}
+}
+
+const testEvent = `{"event_id":"e1","event_timestamp":"2024-05-01T10:00:00Z","event_type":"order","subtype":"settled","order":{"order_id":"o1"}}`
+
+func TestServeHTTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		secret   string
+		wantCode int
+		wantIDs  []string
+	}{
+		{"stored", testEvent, "s3cret", http.StatusOK, []string{"e1"}},
+		{"too large", `{"event_id":"big","pad":"` + strings.Repeat("x", maxBodySize) + `"}`, "s3cret", http.StatusRequestEntityTooLarge, nil},
+		{"unauthorized", testEvent, "wrong", http.StatusUnauthorized, nil},
+		{"invalid json", `{"event_id":`, "s3cret", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			in := New(store, nil, nil, nil)
+			in.SetVerifier(HeaderSecret("X-Secret", "s3cret"))
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			req.Header.Set("X-Secret", tt.secret)
+			rec := httptest.NewRecorder()
+			in.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			msgs, err := store.Claim(context.Background(), time.Now().UTC(), time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "stored", msgs, tt.wantIDs...)
+		})
+	}
+}
+
+func TestProcessOne(t *testing.T) {
+	errHandle := errors.New("handler failed")
+	tests := []struct {
+		name        string
+		body        string
+		attempts    int
+		handleErr   error
+		wantDead    bool
+		wantPending bool
+	}{
+		{name: "success", body: testEvent},
+		{name: "retry", body: testEvent, handleErr: errHandle, wantPending: true},
+		{name: "max attempts", body: testEvent, attempts: 2, handleErr: errHandle, wantDead: true},
+		{name: "unparseable", body: `{"event_id":"e1"}`, wantDead: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryStore()
+			msg := testMessage("e1", -time.Minute)
+			msg.Body = []byte(tt.body)
+			msg.Attempts = tt.attempts
+			mustPut(t, store, msg)
+
+			var handled int
+			in := New(store, nil, func(ctx context.Context, e *funnelfox.Event) error {
+				handled++
+				return tt.handleErr
+			}, nil)
+			in.SetMaxAttempts(3)
+			in.SetBackoff(func(int) time.Duration { return time.Hour })
+
+			processed, err := in.ProcessOne(ctx)
+			if !processed {
+				t.Fatal("ProcessOne did not claim the message")
+			}
+			var dead *DeadLetterError
+			if got := errors.As(err, &dead); got != tt.wantDead {
+				t.Fatalf("ProcessOne error = %v, want dead letter %v", err, tt.wantDead)
+			}
+			if !tt.wantDead && err != nil {
+				t.Fatalf("ProcessOne error = %v", err)
+			}
+			if tt.wantDead && dead.ID != "e1" {
+				t.Errorf("dead letter id = %q", dead.ID)
+			}
+			if tt.handleErr != nil && tt.wantDead && !errors.Is(err, tt.handleErr) {
+				t.Errorf("error %v does not wrap handler error", err)
+			}
+
+			letters, err := store.DeadLetters(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(letters) == 1; got != tt.wantDead {
+				t.Errorf("dead letters = %v", messageIDs(letters))
+			}
+			store.mu.Lock()
+			pending, ok := store.pending["e1"]
+			store.mu.Unlock()
+			if ok != tt.wantPending {
+				t.Fatalf("pending = %v, want %v", ok, tt.wantPending)
+			}
+			if ok && (pending.Attempts != 1 || pending.LastError != errHandle.Error()) {
+				t.Errorf("pending = %+v", pending)
+			}
+		})
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	tests := []struct {
+		name   string
+		client *funnelfox.Client
+	}{
+		{"without client", nil},
+		{"with client", funnelfox.NewClient("org", "secret", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryStore()
+			msg := testMessage("e1", -time.Minute)
+			msg.Body = []byte(testEvent)
+			mustPut(t, store, msg)
+
+			in := New(store, tt.client, func(ctx context.Context, e *funnelfox.Event) error {
+				panic("boom")
+			}, nil)
+			in.SetBackoff(func(int) time.Duration { return time.Hour })
+
+			processed, err := in.ProcessOne(ctx)
+			if !processed || err != nil {
+				t.Fatalf("ProcessOne = %v, %v", processed, err)
+			}
+			store.mu.Lock()
+			pending := store.pending["e1"]
+			store.mu.Unlock()
+			if pending.Attempts != 1 || !strings.Contains(pending.LastError, ErrHandlerPanic.Error()+": boom") {
+				t.Errorf("pending = %+v, want one failed attempt", pending)
+			}
+		})
+	}
+}
+
+func TestRedeliveryAfterAck(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	var handled int
+	in := New(store, nil, func(ctx context.Context, e *funnelfox.Event) error {
+		handled++
+		return nil
+	}, nil)
+
+	for n := 0; n < 2; n++ {
+		rec := httptest.NewRecorder()
+		in.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(testEvent)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("delivery %d status = %d", n+1, rec.Code)
+		}
+		if _, err := in.ProcessOne(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if handled != 1 {
+		t.Errorf("handled %d times, want 1", handled)
+	}
+
+	// 超过 dedupTTL 后被清理，相同ID可以重新处理
+	if err := store.Purge(ctx, time.Now().UTC().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	in.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(testEvent)))
+	if processed, err := in.ProcessOne(ctx); !processed || err != nil {
+		t.Errorf("ProcessOne after purge = %v, %v", processed, err)
+	}
+}
+
+func TestRunReturnsErrors(t *testing.T) {
+	store := NewMemoryStore()
+	bad := testMessage("bad", 0)
+	bad.Body = []byte(`{"event_id":"bad"}`)
+	good := testMessage("good", time.Second)
+	good.Body = []byte(testEvent)
+	mustPut(t, store, bad, good)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := New(store, nil, func(ctx context.Context, e *funnelfox.Event) error { return nil }, nil)
+	in.SetWorkers(1)
+	in.SetPollInterval(10 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- in.Run(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		store.mu.Lock()
+		n := len(store.pending)
+		store.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("messages not processed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	err := <-done
+	var dead *DeadLetterError
+	if !errors.As(err, &dead) || dead.ID != "bad" {
+		t.Fatalf("Run error = %v, want dead letter for bad", err)
+	}
+}
+
+func TestRunErrorsLimit(t *testing.T) {
+	var errs runErrors
+	if err := errs.err(); err != nil {
+		t.Fatalf("empty err() = %v", err)
+	}
+	for n := 0; n < maxRunErrors+5; n++ {
+		errs.add(errors.New("boom"))
+	}
+	if got := len(errs.errs); got != maxRunErrors {
+		t.Errorf("kept %d errors, want %d", got, maxRunErrors)
+	}
+	if err := errs.err(); !strings.Contains(err.Error(), "5 more errors omitted") {
+		t.Errorf("err() = %v", err)
+	}
+}
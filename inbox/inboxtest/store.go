@@ -0,0 +1,220 @@
+// Package inboxtest 提供 inbox.Store 实现的通用测试，供各存储实现（包括独立模块中的实现）复用
+package inboxtest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/byte-power/funnelfox/inbox"
+)
+
+// TestStore 对 newStore 创建的存储运行通用测试，每个用例使用一个新的存储
+func TestStore(t *testing.T, newStore func(t *testing.T) inbox.Store) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s inbox.Store)
+	}{
+		{"claim due in received order", func(t *testing.T, s inbox.Store) {
+			ctx := context.Background()
+			mustPut(t, s, testMessage("b", 2*time.Second), testMessage("a", time.Second), testMessage("later", time.Hour))
+			msgs, err := s.Claim(ctx, testNow.Add(time.Minute), time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "claimed", msgs, "a", "b")
+			if want := testNow.Add(2 * time.Minute); !msgs[0].NextAttemptAt.Equal(want) {
+				t.Errorf("NextAttemptAt = %v, want %v", msgs[0].NextAttemptAt, want)
+			}
+			if string(msgs[0].Body) != `{"event_id":"a"}` {
+				t.Errorf("Body = %s", msgs[0].Body)
+			}
+		}},
+		{"leased messages are not claimed again", func(t *testing.T, s inbox.Store) {
+			ctx := context.Background()
+			mustPut(t, s, testMessage("a", 0))
+			if _, err := s.Claim(ctx, testNow, time.Minute, 1); err != nil {
+				t.Fatal(err)
+			}
+			msgs, err := s.Claim(ctx, testNow.Add(30*time.Second), time.Minute, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "within lease", msgs)
+			msgs, err = s.Claim(ctx, testNow.Add(time.Minute), time.Minute, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "after lease", msgs, "a")
+		}},
+		{"put ignores duplicates", func(t *testing.T, s inbox.Store) {
+			ctx := context.Background()
+			mustPut(t, s, testMessage("a", 0))
+			dup := testMessage("a", 0)
+			dup.Body = []byte(`{"event_id":"a","dup":true}`)
+			mustPut(t, s, dup)
+			msgs, err := s.Claim(ctx, testNow, time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "claimed", msgs, "a")
+			if string(msgs[0].Body) != `{"event_id":"a"}` {
+				t.Errorf("Body = %s, want first body", msgs[0].Body)
+			}
+		}},
+		{"ack removes message", func(t *testing.T, s inbox.Store) {
+			ctx := context.Background()
+			mustPut(t, s, testMessage("a", 0))
+			if err := s.Ack(ctx, "a", testNow); err != nil {
+				t.Fatal(err)
+			}
+			msgs, err := s.Claim(ctx, testNow.Add(time.Hour), time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "claimed", msgs)
+			if err := s.Ack(ctx, "missing", testNow); err != nil {
+				t.Errorf("Ack(missing) = %v", err)
+			}
+		}},
+		{"acked id ignored until purged", func(t *testing.T, s inbox.Store) {
+			ctx := context.Background()
+			mustPut(t, s, testMessage("a", 0), testMessage("b", 0))
+			if err := s.Ack(ctx, "a", testNow); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Ack(ctx, "b", testNow.Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Retry(ctx, testMessage("a", 0)); !errors.Is(err, inbox.ErrNotFound) {
+				t.Errorf("Retry(acked) = %v, want ErrNotFound", err)
+			}
+			mustPut(t, s, testMessage("a", 0))
+			claimed, err := s.Claim(ctx, testNow.Add(time.Hour), time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "redelivered before purge", claimed)
+
+			if err := s.Purge(ctx, testNow); err != nil {
+				t.Fatal(err)
+			}
+			mustPut(t, s, testMessage("a", 0))
+			claimed, err = s.Claim(ctx, testNow.Add(time.Hour), time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "purge keeps tombstones acked at before", claimed)
+
+			if err := s.Purge(ctx, testNow.Add(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			mustPut(t, s, testMessage("a", 0), testMessage("b", 0))
+			claimed, err = s.Claim(ctx, testNow.Add(time.Hour), time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "redelivered after purge", claimed, "a")
+		}},
+		{"retry updates attempts", func(t *testing.T, s inbox.Store) {
+			ctx := context.Background()
+			msg := testMessage("a", 0)
+			mustPut(t, s, msg)
+			msg.Attempts = 2
+			msg.LastError = "boom"
+			msg.NextAttemptAt = testNow.Add(time.Hour)
+			if err := s.Retry(ctx, msg); err != nil {
+				t.Fatal(err)
+			}
+			msgs, err := s.Claim(ctx, testNow.Add(time.Hour), time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "claimed", msgs, "a")
+			if msgs[0].Attempts != 2 || msgs[0].LastError != "boom" {
+				t.Errorf("claimed = %+v", msgs[0])
+			}
+			if err := s.Retry(ctx, testMessage("missing", 0)); !errors.Is(err, inbox.ErrNotFound) {
+				t.Errorf("Retry(missing) = %v, want ErrNotFound", err)
+			}
+		}},
+		{"dead letter and replay", func(t *testing.T, s inbox.Store) {
+			ctx := context.Background()
+			msg := testMessage("a", 0)
+			mustPut(t, s, msg)
+			msg.Attempts = 3
+			msg.LastError = "boom"
+			if err := s.DeadLetter(ctx, msg); err != nil {
+				t.Fatal(err)
+			}
+			mustPut(t, s, testMessage("a", 0))
+			claimed, err := s.Claim(ctx, testNow.Add(time.Hour), time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "claimed dead", claimed)
+			dead, err := s.DeadLetters(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "dead letters", dead, "a")
+			if dead[0].Attempts != 3 || dead[0].LastError != "boom" {
+				t.Errorf("dead letter = %+v", dead[0])
+			}
+
+			if err := s.Replay(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Replay(ctx, "a"); !errors.Is(err, inbox.ErrNotFound) {
+				t.Errorf("second Replay = %v, want ErrNotFound", err)
+			}
+			dead, err = s.DeadLetters(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "dead letters after replay", dead)
+			claimed, err = s.Claim(ctx, testNow, time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertIDs(t, "claimed replayed", claimed, "a")
+			if claimed[0].Attempts != 0 || claimed[0].LastError != "" {
+				t.Errorf("replayed = %+v, want reset attempts", claimed[0])
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStore(t))
+		})
+	}
+}
+
+var testNow = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func testMessage(id string, received time.Duration) inbox.Message {
+	at := testNow.Add(received)
+	return inbox.Message{ID: id, Body: []byte(`{"event_id":"` + id + `"}`), ReceivedAt: at, NextAttemptAt: at}
+}
+
+func assertIDs(t *testing.T, name string, got []inbox.Message, want ...string) {
+	t.Helper()
+	ids := make([]string, 0, len(got))
+	for _, msg := range got {
+		ids = append(ids, msg.ID)
+	}
+	if !slices.Equal(ids, want) && (len(ids) != 0 || len(want) != 0) {
+		t.Fatalf("%s = %v, want %v", name, ids, want)
+	}
+}
+
+func mustPut(t *testing.T, s inbox.Store, msgs ...inbox.Message) {
+	t.Helper()
+	for _, msg := range msgs {
+		if err := s.Put(context.Background(), msg); err != nil {
+			t.Fatalf("Put(%s): %v", msg.ID, err)
+		}
+	}
+}
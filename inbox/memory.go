@@ -0,0 +1,143 @@
+package inbox
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore 内存存储，进程退出后数据丢失，适用于测试和开发
+type MemoryStore struct {
+	mu      sync.Mutex
+	pending map[string]Message
+	dead    map[string]Message
+	acked   map[string]time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		pending: make(map[string]Message),
+		dead:    make(map[string]Message),
+		acked:   make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[msg.ID]; ok {
+		return nil
+	}
+	if _, ok := s.dead[msg.ID]; ok {
+		return nil
+	}
+	if _, ok := s.acked[msg.ID]; ok {
+		return nil
+	}
+	s.pending[msg.ID] = msg
+	return nil
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []Message
+	for _, msg := range s.pending {
+		if !msg.NextAttemptAt.After(now) {
+			due = append(due, msg)
+		}
+	}
+	sortByReceived(due)
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].NextAttemptAt = now.Add(lease)
+		s.pending[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+func (s *MemoryStore) Ack(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[id]; !ok {
+		return nil
+	}
+	delete(s.pending, id)
+	s.acked[id] = at
+	return nil
+}
+
+func (s *MemoryStore) Retry(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[msg.ID]; !ok {
+		return ErrNotFound
+	}
+	s.pending[msg.ID] = msg
+	return nil
+}
+
+func (s *MemoryStore) DeadLetter(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, msg.ID)
+	s.dead[msg.ID] = msg
+	return nil
+}
+
+func (s *MemoryStore) DeadLetters(ctx context.Context) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]Message, 0, len(s.dead))
+	for _, msg := range s.dead {
+		res = append(res, msg)
+	}
+	sortByReceived(res)
+	return res, nil
+}
+
+func (s *MemoryStore) Replay(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.dead[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.dead, id)
+	s.pending[id] = resetForReplay(msg)
+	return nil
+}
+
+func (s *MemoryStore) Purge(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, at := range s.acked {
+		if at.Before(before) {
+			delete(s.acked, id)
+		}
+	}
+	return nil
+}
+
+func sortByReceived(msgs []Message) {
+	sort.Slice(msgs, func(i, j int) bool {
+		if msgs[i].ReceivedAt.Equal(msgs[j].ReceivedAt) {
+			return msgs[i].ID < msgs[j].ID
+		}
+		return msgs[i].ReceivedAt.Before(msgs[j].ReceivedAt)
+	})
+}
+
+// resetForReplay 重放死信时清零尝试次数并立即可被领取
+func resetForReplay(msg Message) Message {
+	msg.Attempts = 0
+	msg.NextAttemptAt = time.Time{}
+	msg.LastError = ""
+	return msg
+}
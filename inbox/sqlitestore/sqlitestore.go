@@ -0,0 +1,171 @@
+// Package sqlitestore 提供基于 SQLite 的 inbox.Store 实现
+//
+// 作为独立模块发布，只使用 SDK 的调用方不会引入 SQLite 相关依赖
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/byte-power/funnelfox/inbox"
+)
+
+// Store 基于 SQLite 的收件箱存储
+//
+// 不依赖具体驱动，调用方需自行导入驱动（如 modernc.org/sqlite 或 github.com/mattn/go-sqlite3）并打开 db
+type Store struct {
+	db *sql.DB
+}
+
+var _ inbox.Store = (*Store)(nil)
+
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS funnelfox_inbox (
+	id              TEXT PRIMARY KEY,
+	body            BLOB NOT NULL,
+	received_at     INTEGER NOT NULL,
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at INTEGER NOT NULL DEFAULT 0,
+	last_error      TEXT NOT NULL DEFAULT '',
+	dead            INTEGER NOT NULL DEFAULT 0,
+	acked_at        INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS funnelfox_inbox_due ON funnelfox_inbox (dead, acked_at, next_attempt_at, received_at);`
+
+// New 创建 SQLite 存储，表不存在时自动创建
+func New(ctx context.Context, db *sql.DB) (*Store, error) {
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// 时间以 UnixNano 保存，零值保存为 0
+func toUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnix(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
+
+func (s *Store) Put(ctx context.Context, msg inbox.Message) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO funnelfox_inbox (id, body, received_at, attempts, next_attempt_at, last_error)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		msg.ID, msg.Body, toUnix(msg.ReceivedAt), msg.Attempts, toUnix(msg.NextAttemptAt), msg.LastError)
+	return err
+}
+
+func (s *Store) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]inbox.Message, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	msgs, err := queryMessages(ctx, tx,
+		`SELECT id, body, received_at, attempts, next_attempt_at, last_error FROM funnelfox_inbox
+		WHERE dead = 0 AND acked_at = 0 AND next_attempt_at <= ? ORDER BY received_at, id LIMIT ?`,
+		now.UnixNano(), limit)
+	if err != nil {
+		return nil, err
+	}
+	next := now.Add(lease)
+	for i := range msgs {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE funnelfox_inbox SET next_attempt_at = ? WHERE id = ?`,
+			next.UnixNano(), msgs[i].ID); err != nil {
+			return nil, err
+		}
+		msgs[i].NextAttemptAt = next
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// Ack 保留一行只有 ID 和确认时间的记录，清空请求体
+func (s *Store) Ack(ctx context.Context, id string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE funnelfox_inbox SET acked_at = ?, body = X'' WHERE id = ? AND dead = 0 AND acked_at = 0`,
+		at.UnixNano(), id)
+	return err
+}
+
+func (s *Store) Retry(ctx context.Context, msg inbox.Message) error {
+	return s.update(ctx, msg, false)
+}
+
+func (s *Store) DeadLetter(ctx context.Context, msg inbox.Message) error {
+	return s.update(ctx, msg, true)
+}
+
+func (s *Store) update(ctx context.Context, msg inbox.Message, dead bool) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE funnelfox_inbox SET attempts = ?, next_attempt_at = ?, last_error = ?, dead = ? WHERE id = ? AND dead = 0 AND acked_at = 0`,
+		msg.Attempts, toUnix(msg.NextAttemptAt), msg.LastError, dead, msg.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return inbox.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) DeadLetters(ctx context.Context) ([]inbox.Message, error) {
+	return queryMessages(ctx, s.db,
+		`SELECT id, body, received_at, attempts, next_attempt_at, last_error FROM funnelfox_inbox
+		WHERE dead = 1 ORDER BY received_at, id`)
+}
+
+func (s *Store) Replay(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE funnelfox_inbox SET attempts = 0, next_attempt_at = 0, last_error = '', dead = 0
+		WHERE id = ? AND dead = 1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return inbox.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) Purge(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM funnelfox_inbox WHERE acked_at > 0 AND acked_at < ?`, before.UnixNano())
+	return err
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func queryMessages(ctx context.Context, q queryer, query string, args ...any) ([]inbox.Message, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var msgs []inbox.Message
+	for rows.Next() {
+		var msg inbox.Message
+		var receivedAt, nextAttemptAt int64
+		if err := rows.Scan(&msg.ID, &msg.Body, &receivedAt, &msg.Attempts, &nextAttemptAt, &msg.LastError); err != nil {
+			return nil, err
+		}
+		msg.ReceivedAt = fromUnix(receivedAt)
+		msg.NextAttemptAt = fromUnix(nextAttemptAt)
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
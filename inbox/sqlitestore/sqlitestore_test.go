@@ -0,0 +1,27 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/byte-power/funnelfox/inbox"
+	"github.com/byte-power/funnelfox/inbox/inboxtest"
+	_ "modernc.org/sqlite"
+)
+
+func TestStore(t *testing.T) {
+	inboxtest.TestStore(t, func(t *testing.T) inbox.Store {
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "inbox.db"))
+		if err != nil {
+			t.Fatalf("sql.Open: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		s, err := New(context.Background(), db)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		return s
+	})
+}
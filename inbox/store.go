@@ -0,0 +1,46 @@
+// Package inbox 提供持久化的 webhook 收件箱：HTTP 处理器校验请求、保存原始内容后立即应答，
+// 由后台 worker 解析事件并调用业务处理函数，失败时按退避策略重试，超过次数后转入死信。
+package inbox
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound 消息不存在
+var ErrNotFound = errors.New("inbox: message not found")
+
+// Message 收件箱中的一条 webhook 消息
+type Message struct {
+	ID            string    `json:"id"`              // 消息ID，默认使用事件的 event_id
+	Body          []byte    `json:"body"`            // 原始请求体
+	ReceivedAt    time.Time `json:"received_at"`     // 接收时间
+	Attempts      int       `json:"attempts"`        // 已尝试处理次数
+	NextAttemptAt time.Time `json:"next_attempt_at"` // 下次可被领取的时间
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// Store 收件箱存储
+//
+// 待处理消息在 NextAttemptAt 之后可被领取；领取时 NextAttemptAt 被推迟一个租约时长，
+// 处理进程异常退出时消息会在租约到期后被重新领取。
+type Store interface {
+	// Put 保存新消息，ID 已存在（待处理、死信或尚未被 Purge 的已确认记录）时忽略
+	Put(ctx context.Context, msg Message) error
+	// Claim 领取最多 limit 条 NextAttemptAt <= now 的消息，并将其 NextAttemptAt 设置为 now+lease
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Message, error)
+	// Ack 处理成功，待处理消息转为已确认记录（tombstone），记录确认时间 at，
+	// 保留到 Purge 删除为止，期间相同 ID 的重复投递被 Put 忽略
+	Ack(ctx context.Context, id string, at time.Time) error
+	// Retry 更新尝试次数、错误和下次尝试时间
+	Retry(ctx context.Context, msg Message) error
+	// DeadLetter 将消息移入死信
+	DeadLetter(ctx context.Context, msg Message) error
+	// DeadLetters 列出死信
+	DeadLetters(ctx context.Context) ([]Message, error)
+	// Replay 将死信移回待处理队列，尝试次数清零
+	Replay(ctx context.Context, id string) error
+	// Purge 删除 before 之前确认的记录
+	Purge(ctx context.Context, before time.Time) error
+}
@@ -0,0 +1,29 @@
+package inbox_test
+
+import (
+	"testing"
+
+	"github.com/byte-power/funnelfox/inbox"
+	"github.com/byte-power/funnelfox/inbox/inboxtest"
+)
+
+func TestStores(t *testing.T) {
+	tests := []struct {
+		name     string
+		newStore func(t *testing.T) inbox.Store
+	}{
+		{"memory", func(t *testing.T) inbox.Store { return inbox.NewMemoryStore() }},
+		{"file", func(t *testing.T) inbox.Store {
+			s, err := inbox.NewFileStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewFileStore: %v", err)
+			}
+			return s
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inboxtest.TestStore(t, tt.newStore)
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package funnelfox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jsonObject 原始 JSON 对象，保留键顺序和每个值的原始字节
+type jsonObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// jsonMeta 模型解码时保留的原始信息，用于无损重新编码
+// 模型中以指针保存，保持模型可以用 == 比较
+type jsonMeta struct {
+	raw      *jsonObject
+	timeErrs []error // 解析失败的时间字段（*TimeParseError），字段路径相对当前对象
+}
+
+// object 返回解码时的原始对象，模型不是解码得到的时返回 nil
+func (m *jsonMeta) object() *jsonObject {
+	if m == nil {
+		return nil
+	}
+	return m.raw
+}
+
+// timeErrors 返回解码时解析失败的时间字段
+func (m *jsonMeta) timeErrors() []error {
+	if m == nil {
+		return nil
+	}
+	return m.timeErrs
+}
+
+// parseJSONObject 解析 JSON 对象，输入为 null 时返回 nil
+func parseJSONObject(data []byte) (*jsonObject, error) {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, errors.New("expected JSON object")
+	}
+	obj := &jsonObject{values: make(map[string]json.RawMessage)}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errors.New("expected JSON object key")
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if _, dup := obj.values[key]; !dup {
+			obj.keys = append(obj.keys, key)
+		}
+		obj.values[key] = value
+	}
+	return obj, nil
+}
+
+// extra 返回不在 known 中的键值
+func (o *jsonObject) extra(known map[string]bool) UnknownFields {
+	var extra map[string]json.RawMessage
+	for _, k := range o.keys {
+		if known[k] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[k] = o.values[k]
+	}
+	return newUnknownFields(extra)
+}
+
+// decodeObject 解析原始对象并解码到 target（不能是实现了 UnmarshalJSON 的类型本身，否则会递归）
+// 输入为 null 时返回 nil 且不修改 target
+func decodeObject(data []byte, target any) (*jsonObject, error) {
+	obj, err := parseJSONObject(data)
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// jsonField 结构体中参与 JSON 编码的字段（已展开内嵌结构体）
+type jsonField struct {
+	name      string
+	omitEmpty bool
+	index     []int
+}
+
+var jsonFieldsCache sync.Map // reflect.Type -> []jsonField
+
+// jsonFields 按声明顺序列出 t 的 JSON 字段，规则与 encoding/json 一致：
+// 跳过未导出字段和 json:"-"，展开没有指定名称的内嵌结构体
+func jsonFields(t reflect.Type) []jsonField {
+	if cached, ok := jsonFieldsCache.Load(t); ok {
+		return cached.([]jsonField)
+	}
+	var fields []jsonField
+	seen := make(map[string]bool)
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			idx := append(append([]int(nil), index...), i)
+			if sf.Anonymous && name == "" {
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft, idx)
+					continue
+				}
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			fields = append(fields, jsonField{
+				name:      name,
+				omitEmpty: strings.Contains(opts, "omitempty"),
+				index:     idx,
+			})
+		}
+	}
+	walk(t, nil)
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+// knownJSONKeys 返回 t 的 JSON 字段名集合
+func knownJSONKeys(t reflect.Type) map[string]bool {
+	known := make(map[string]bool)
+	for _, f := range jsonFields(t) {
+		known[f.name] = true
+	}
+	return known
+}
+
+// marshalPreserving 编码结构体 v，尽量还原解码时的原始 JSON：
+// 原始对象中的键保持原顺序，值未被修改时输出原始字节，未知字段从 extra 中输出；
+// 新增的已知字段按声明顺序追加，extra 中新增的键按字母序追加。
+// 输出为紧凑 JSON（encoding/json 会去除 MarshalJSON 结果中的空白），
+// 因此只有原始输入是紧凑格式时未修改的对象才与输入逐字节一致
+func marshalPreserving(v any, extra UnknownFields, orig *jsonObject) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	extraValues := extra.values()
+	encoded := make(map[string]json.RawMessage)
+	var order []string
+	for _, f := range jsonFields(rv.Type()) {
+		fv, err := rv.FieldByIndexErr(f.index)
+		if err != nil {
+			// 内嵌指针为 nil
+			continue
+		}
+		raw, inOrig := json.RawMessage(nil), false
+		if orig != nil {
+			raw, inOrig = orig.values[f.name]
+			if !inOrig && fv.IsZero() {
+				// 原始对象中没有该字段且未被设置，保持缺省
+				continue
+			}
+		}
+		if !inOrig && f.omitEmpty && isEmptyJSONValue(fv) {
+			continue
+		}
+		if inOrig && isTimeType(fv.Type()) && sameTimeValue(raw, fv) {
+			encoded[f.name] = raw
+			order = append(order, f.name)
+			continue
+		}
+		bs, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return nil, err
+		}
+		if inOrig && sameJSONValue(raw, bs, fv) {
+			bs = raw
+		} else if f.omitEmpty && isEmptyJSONValue(fv) {
+			continue
+		}
+		encoded[f.name] = bs
+		order = append(order, f.name)
+	}
+
+	var buf bytes.Buffer
+	written := make(map[string]bool)
+	write := func(key string, value json.RawMessage) error {
+		if written[key] {
+			return nil
+		}
+		if len(written) > 0 {
+			buf.WriteByte(',')
+		}
+		written[key] = true
+		kb, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(value)
+		return nil
+	}
+
+	buf.WriteByte('{')
+	if orig != nil {
+		for _, k := range orig.keys {
+			if value, ok := encoded[k]; ok {
+				if err := write(k, value); err != nil {
+					return nil, err
+				}
+			} else if value, ok := extraValues[k]; ok {
+				if err := write(k, value); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, k := range order {
+		if err := write(k, encoded[k]); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range extra.Keys() {
+		if err := write(k, extraValues[k]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func isTimeType(t reflect.Type) bool {
+	return t == timeType || (t.Kind() == reflect.Pointer && t.Elem() == timeType)
+}
+
+// sameJSONValue 判断字段的原始 JSON 值 raw 与当前编码结果 encoded 是否表示同一个值
+//
+// 先比较去除空白后的字节，只有不一致且字段不包含自定义编码的类型（如 map[string]any 的键顺序、
+// 数字写法不同）时才解码 raw 后逐值比较。模型类型的编码结果本身会尽量还原原始字节，
+// 字节不一致即表示已修改，不再解码整棵子树，避免逐层重复解码
+func sameJSONValue(raw, encoded json.RawMessage, fv reflect.Value) bool {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err == nil && bytes.Equal(compact.Bytes(), encoded) {
+		return true
+	}
+	if hasCustomMarshaler(fv.Type()) {
+		return false
+	}
+	ptr := reflect.New(fv.Type())
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(ptr.Elem().Interface(), fv.Interface())
+}
+
+var customMarshalerCache sync.Map // reflect.Type -> bool
+
+// hasCustomMarshaler t 或其指针、切片、数组、map 元素是否实现了 json.Marshaler
+func hasCustomMarshaler(t reflect.Type) bool {
+	if cached, ok := customMarshalerCache.Load(t); ok {
+		return cached.(bool)
+	}
+	res := false
+	for inner := t; ; inner = inner.Elem() {
+		if inner.Implements(marshalerType) || reflect.PointerTo(inner).Implements(marshalerType) {
+			res = true
+			break
+		}
+		if k := inner.Kind(); k != reflect.Pointer && k != reflect.Slice && k != reflect.Array && k != reflect.Map {
+			break
+		}
+	}
+	customMarshalerCache.Store(t, res)
+	return res
+}
+
+// sameTimeValue 时间字段按 parseTime 解析后比较，原始值无法解析且当前为 nil 时视为未修改
+func sameTimeValue(raw json.RawMessage, fv reflect.Value) bool {
+	var current *time.Time
+	if fv.Kind() == reflect.Pointer {
+		if !fv.IsNil() {
+			t := fv.Elem().Interface().(time.Time)
+			current = &t
+		}
+	} else {
+		t := fv.Interface().(time.Time)
+		current = &t
+	}
+
+	var s *string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return false
+	}
+	if s == nil || *s == "" {
+		return current == nil
+	}
+	t, err := parseTime(*s)
+	if err != nil {
+		return current == nil
+	}
+	return current != nil && t.Equal(*current)
+}
+
+// isEmptyJSONValue 与 encoding/json 的 omitempty 判断一致
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// prefixTimeErrors 为嵌套对象的时间解析错误加上字段路径前缀
+func prefixTimeErrors(prefix string, errs []error) []error {
+	res := make([]error, 0, len(errs))
+	for _, err := range errs {
+		var tpe *TimeParseError
+		if errors.As(err, &tpe) {
+			res = append(res, &TimeParseError{Field: prefix + tpe.Field, Value: tpe.Value, Err: tpe.Err})
+			continue
+		}
+		res = append(res, err)
+	}
+	return res
+}
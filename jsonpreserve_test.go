@@ -0,0 +1,217 @@
+package funnelfox
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTrip 解码 data 到 v 后重新编码
+func roundTrip(t *testing.T, data []byte, v any) []byte {
+	t.Helper()
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestGoldenRoundTrip(t *testing.T) {
+	tests := []struct {
+		file string
+		v    func() any
+	}{
+		{"event_subscription.json", func() any { return &Event{} }},
+		{"event_order.json", func() any { return &Event{} }},
+		{"transactions.json", func() any { return &TransactionReportResponse{} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			golden, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden = bytes.TrimSpace(golden)
+
+			// 紧凑输入逐字节还原
+			if out := roundTrip(t, golden, tt.v()); !bytes.Equal(out, golden) {
+				t.Errorf("round trip mismatch\n got: %s\nwant: %s", out, golden)
+			}
+
+			// 带缩进的输入还原为紧凑格式
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, golden, "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			if out := roundTrip(t, indented.Bytes(), tt.v()); !bytes.Equal(out, golden) {
+				t.Errorf("indented round trip mismatch\n got: %s\nwant: %s", out, golden)
+			}
+		})
+	}
+}
+
+func TestRoundTripEdits(t *testing.T) {
+	golden, err := os.ReadFile(filepath.Join("testdata", "event_subscription.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden = bytes.TrimSpace(golden)
+
+	tests := []struct {
+		name string
+		edit func(e *Event)
+		old  string
+		new  string
+	}{
+		{
+			name: "top-level field",
+			edit: func(e *Event) { e.Subtype = EventSubtypeSubscriptionExpiration },
+			old:  `"subtype":"renewing"`,
+			new:  `"subtype":"expiration"`,
+		},
+		{
+			name: "nested field",
+			edit: func(e *Event) { e.Subscription.PricePoint.Currency.Code = "EUR" },
+			old:  `"code":"USD"`,
+			new:  `"code":"EUR"`,
+		},
+		{
+			name: "time field",
+			edit: func(e *Event) {
+				at := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+				e.Subscription.CurrentPeriodEndsAt = &at
+			},
+			old: `"current_period_ends_at":"2024-06-01T10:00:00Z"`,
+			new: `"current_period_ends_at":"2024-07-01T10:00:00Z"`,
+		},
+		{
+			name: "unknown field",
+			edit: func(e *Event) { e.Extra.Set("webhook_version", json.RawMessage(`4`)) },
+			old:  `"webhook_version":3`,
+			new:  `"webhook_version":4`,
+		},
+		{
+			name: "new unknown field appended",
+			edit: func(e *Event) { e.Subscription.Extra.Set("added", json.RawMessage(`true`)) },
+			old:  `"cohort":"2024-04"}`,
+			new:  `"cohort":"2024-04","added":true}`,
+		},
+		{
+			name: "deleted unknown field",
+			edit: func(e *Event) { e.Subscription.Extra.Delete("cohort") },
+			old:  `,"cohort":"2024-04"`,
+			new:  ``,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e Event
+			if err := json.Unmarshal(golden, &e); err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(&e)
+			out, err := json.Marshal(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := strings.Replace(string(golden), tt.old, tt.new, 1)
+			if string(out) != want {
+				t.Errorf("got:  %s\nwant: %s", out, want)
+			}
+		})
+	}
+}
+
+func TestUnknownFieldsCopyOnWrite(t *testing.T) {
+	var c Currency
+	if err := json.Unmarshal([]byte(`{"code":"USD","sign":"$"}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	copied := c
+	copied.Extra.Set("sign", json.RawMessage(`"US$"`))
+	copied.Extra.Set("name", json.RawMessage(`"dollar"`))
+
+	if v, _ := c.Extra.Get("sign"); string(v) != `"$"` {
+		t.Errorf("original sign = %s", v)
+	}
+	if got := copied.Extra.Keys(); strings.Join(got, ",") != "name,sign" {
+		t.Errorf("keys = %v", got)
+	}
+	m := copied.Extra.Map()
+	m["sign"] = json.RawMessage(`"x"`)
+	if v, _ := copied.Extra.Get("sign"); string(v) != `"US$"` {
+		t.Errorf("Map() is not a copy")
+	}
+	copied.Extra.Delete("name")
+	copied.Extra.Delete("sign")
+	if copied.Extra.Len() != 0 || c.Extra.Len() != 1 {
+		t.Errorf("len = %d, original %d", copied.Extra.Len(), c.Extra.Len())
+	}
+}
+
+func TestModelsComparable(t *testing.T) {
+	// 模型可以作为 map 键和用 == 比较
+	seen := map[Currency]bool{{Code: "USD"}: true}
+	if !seen[Currency{Code: "USD"}] {
+		t.Error("Currency not usable as map key")
+	}
+	if (Feature{Ident: "pro"}) != (Feature{Ident: "pro"}) {
+		t.Error("equal features compare unequal")
+	}
+	var decoded Feature
+	if err := json.Unmarshal([]byte(`{"ident":"pro"}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if copied := decoded; copied != decoded {
+		t.Error("copy of decoded feature compares unequal")
+	}
+}
+
+func TestMarshalWithoutDecode(t *testing.T) {
+	extra := Feature{Ident: "pro"}
+	extra.Extra.Set("feature_type", json.RawMessage(`"timebased"`))
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"currency", Currency{Code: "USD"}, `{"code":"USD","minor_units":0,"title":"","symbol":""}`},
+		{"feature", Feature{Ident: "pro"}, `{"ident":"pro"}`},
+		{"feature with unknown field", extra, `{"ident":"pro","feature_type":"timebased"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkEventMarshal(b *testing.B) {
+	golden, err := os.ReadFile(filepath.Join("testdata", "event_subscription.json"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var e Event
+	if err := json.Unmarshal(golden, &e); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package funnelfox
+
+import "context"
+
+// Limiter 请求限流器接口，golang.org/x/time/rate.Limiter 满足该接口
+type Limiter interface {
+	// Wait 阻塞直到允许发送请求，或 ctx 结束时返回错误
+	Wait(ctx context.Context) error
+}
+
+// SetLimiter 设置请求限流器，l 为 nil 时不限流
+func (c *Client) SetLimiter(l Limiter) {
+	c.limiter = l
+}
@@ -0,0 +1,28 @@
+package funnelfox
+
+import "time"
+
+// MetricsRecorder 指标记录器接口
+type MetricsRecorder interface {
+	// ObserveRequest 记录一次 API 请求及其耗时，err 为 nil 表示成功
+	ObserveRequest(endpoint string, duration time.Duration, err error)
+	// IncAPIError 记录一次 API 返回的错误（按 APIError.Type）
+	IncAPIError(endpoint, errorType string)
+	// IncEvent 记录一次 webhook 事件
+	IncEvent(eventType EventType, subtype EventSubtype)
+}
+
+// NopMetricsRecorder 空指标记录器（不记录任何指标）
+type NopMetricsRecorder struct{}
+
+func (n *NopMetricsRecorder) ObserveRequest(endpoint string, duration time.Duration, err error) {}
+func (n *NopMetricsRecorder) IncAPIError(endpoint, errorType string)                            {}
+func (n *NopMetricsRecorder) IncEvent(eventType EventType, subtype EventSubtype)                {}
+
+// SetMetricsRecorder 设置指标记录器，m 为 nil 时使用 NopMetricsRecorder
+func (c *Client) SetMetricsRecorder(m MetricsRecorder) {
+	if m == nil {
+		m = &NopMetricsRecorder{}
+	}
+	c.metrics = m
+}
@@ -0,0 +1,149 @@
+package funnelfox
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMetrics 记录调用的 MetricsRecorder
+type fakeMetrics struct {
+	mu        sync.Mutex
+	requests  []string
+	apiErrors []string
+	events    []string
+}
+
+func (f *fakeMetrics) ObserveRequest(endpoint string, duration time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	f.requests = append(f.requests, endpoint+" "+result)
+}
+
+func (f *fakeMetrics) IncAPIError(endpoint, errorType string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.apiErrors = append(f.apiErrors, endpoint+" "+errorType)
+}
+
+func (f *fakeMetrics) IncEvent(eventType EventType, subtype EventSubtype) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, string(eventType)+"."+string(subtype))
+}
+
+const testEvent = `{"event_id":"e1","event_timestamp":"2024-05-01T10:00:00Z","event_type":"order","subtype":"settled"}`
+
+func TestRequestMetrics(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantRequests  []string
+		wantAPIErrors []string
+	}{
+		{
+			name:         "success",
+			status:       http.StatusOK,
+			body:         okResponse,
+			wantRequests: []string{"/subscription/resume success"},
+		},
+		{
+			name:          "api errors",
+			status:        http.StatusBadRequest,
+			body:          `{"status":"error","error":[{"type":"invalid_request","msg":"a"},{"type":"not_found","msg":"b"}]}`,
+			wantRequests:  []string{"/subscription/resume error"},
+			wantAPIErrors: []string{"/subscription/resume invalid_request", "/subscription/resume not_found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &fakeMetrics{}
+			c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				return jsonResponse(tt.status, tt.body), nil
+			})
+			c.SetMetricsRecorder(m)
+			_ = c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"})
+			assertStrings(t, "requests", m.requests, tt.wantRequests)
+			assertStrings(t, "api errors", m.apiErrors, tt.wantAPIErrors)
+		})
+	}
+}
+
+func TestEventMetrics(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(c *Client, data []byte) error
+		data  string
+		want  []string
+	}{
+		{
+			name: "client parse",
+			parse: func(c *Client, data []byte) error {
+				_, err := c.ParseEvent(data)
+				return err
+			},
+			data: testEvent,
+			want: []string{"order.settled"},
+		},
+		{
+			name: "handle event",
+			parse: func(c *Client, data []byte) error {
+				_, err := c.HandleEvent(data, func(ctx context.Context, event *Event) error { return nil })
+				return err
+			},
+			data: testEvent,
+			want: []string{"order.settled"},
+		},
+		{
+			name: "client parse error",
+			parse: func(c *Client, data []byte) error {
+				_, err := c.ParseEvent(data)
+				if err == nil {
+					t.Error("expected parse error")
+				}
+				return nil
+			},
+			data: `{"event_id":1}`,
+		},
+		{
+			name: "package parse records nothing",
+			parse: func(c *Client, data []byte) error {
+				_, err := ParseEvent(data)
+				return err
+			},
+			data: testEvent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &fakeMetrics{}
+			c := NewClient("org", "secret", nil)
+			c.SetMetricsRecorder(m)
+			if err := tt.parse(c, []byte(tt.data)); err != nil {
+				t.Fatal(err)
+			}
+			assertStrings(t, "events", m.events, tt.want)
+		})
+	}
+}
+
+func assertStrings(t *testing.T, name string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s = %q, want %q", name, got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s = %q, want %q", name, got, want)
+			return
+		}
+	}
+}
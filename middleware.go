@@ -0,0 +1,38 @@
+package funnelfox
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request 一次 API 调用的请求对象，中间件可以读取和修改
+type Request struct {
+	Context       context.Context // 请求上下文
+	Endpoint      string          // API 路径，如 /subscription/pause
+	Body          any             // 请求体，发送前序列化为 JSON
+	WithSecretKey bool            // 是否需要携带 ff-secret-key
+	Header        http.Header     // 请求头，进入中间件链前已设置 Content-Type、Accept 和 ff-secret-key，中间件可以覆盖
+}
+
+// Handler 执行一次 API 调用，返回解码后的响应信封
+// API 返回错误时 Response 和 *Error 可能同时非 nil
+type Handler func(req *Request) (*Response, *Error)
+
+// Middleware 包装 Handler 的中间件
+type Middleware func(next Handler) Handler
+
+// Use 注册中间件，先注册的中间件位于外层
+func (c *Client) Use(middleware ...Middleware) {
+	mws := make([]Middleware, 0, len(c.middleware)+len(middleware))
+	mws = append(mws, c.middleware...)
+	c.middleware = append(mws, middleware...)
+}
+
+// handler 构造包含所有中间件的 Handler
+func (c *Client) handler() Handler {
+	h := Handler(c.send)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,108 @@
+package funnelfox
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareHeaders(t *testing.T) {
+	setHeader := func(key, value string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *Request) (*Response, *Error) {
+				req.Header.Set(key, value)
+				return next(req)
+			}
+		}
+	}
+	tests := []struct {
+		name       string
+		middleware []Middleware
+		want       map[string]string
+	}{
+		{
+			name: "defaults",
+			want: map[string]string{
+				"Content-Type":  "application/json",
+				"Accept":        "application/json",
+				"Ff-Secret-Key": "secret",
+			},
+		},
+		{
+			name:       "override secret key",
+			middleware: []Middleware{setHeader("ff-secret-key", "other")},
+			want: map[string]string{
+				"Ff-Secret-Key": "other",
+				"Content-Type":  "application/json",
+			},
+		},
+		{
+			name:       "override accept",
+			middleware: []Middleware{setHeader("Accept", "application/vnd.funnelfox+json")},
+			want:       map[string]string{"Accept": "application/vnd.funnelfox+json"},
+		},
+		{
+			name:       "inner middleware wins",
+			middleware: []Middleware{setHeader("X-Tenant", "outer"), setHeader("X-Tenant", "inner")},
+			want:       map[string]string{"X-Tenant": "inner", "Ff-Secret-Key": "secret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				got = req.Header.Clone()
+				return jsonResponse(http.StatusOK, okResponse), nil
+			})
+			c.Use(tt.middleware...)
+			if err := c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"}); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.want {
+				if got.Get(k) != v {
+					t.Errorf("%s = %q, want %q", k, got.Get(k), v)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *Request) (*Response, *Error) {
+				calls = append(calls, name+" before")
+				resp, err := next(req)
+				calls = append(calls, name+" after")
+				return resp, err
+			}
+		}
+	}
+	c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		calls = append(calls, "send")
+		return jsonResponse(http.StatusOK, okResponse), nil
+	})
+	c.Use(trace("a"))
+	c.Use(trace("b"))
+	if err := c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	assertStrings(t, "calls", calls, []string{"a before", "b before", "send", "b after", "a after"})
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		t.Error("request should not be sent")
+		return jsonResponse(http.StatusOK, okResponse), nil
+	})
+	c.Use(func(next Handler) Handler {
+		return func(req *Request) (*Response, *Error) {
+			return nil, NewError("blocked " + req.Endpoint)
+		}
+	})
+	err := c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"})
+	if err == nil || !strings.Contains(err.Error(), "blocked /subscription/resume") {
+		t.Fatalf("err = %v", err)
+	}
+}
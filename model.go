@@ -2,11 +2,15 @@ package funnelfox
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
 const timeFormat = "2006-01-02T15:04:05.999999"
 
+// requestTimeFormat 请求中时间字段的格式：转换为 UTC 后的 RFC3339（微秒精度，以 Z 结尾）
+const requestTimeFormat = "2006-01-02T15:04:05.999999Z07:00"
+
 // Response FunnelFox Billing API 响应结构
 type Response struct {
 	Data   json.RawMessage `json:"data,omitempty"`
@@ -48,20 +52,6 @@ type OneClickPurchaseRequest struct {
 // OneClickPurchaseResponse 一键购买响应
 type OneClickPurchaseResponse PaymentResult
 
-// rawPayment 原始支付信息（用于解析）
-type rawPayment struct {
-	Amount        string   `json:"amount"`
-	CreatedAt     string   `json:"created_at"`
-	Currency      Currency `json:"currency"`
-	Last4         string   `json:"last4"`
-	Network       string   `json:"network"`
-	OneoffID      *string  `json:"oneoff_id"`
-	OrderID       string   `json:"order_id"`
-	PaymentMethod string   `json:"payment_method"`
-	Refunded      string   `json:"refunded"`
-	SubsID        string   `json:"subs_id"`
-}
-
 // Payment 支付信息
 type Payment struct {
 	Amount        string     `json:"amount"`
@@ -74,11 +64,9 @@ type Payment struct {
 	PaymentMethod string     `json:"payment_method"`
 	Refunded      string     `json:"refunded"`
 	SubsID        string     `json:"subs_id"`
-}
 
-// rawPaymentsHistoryResponse 原始支付历史响应
-type rawPaymentsHistoryResponse struct {
-	Payments []rawPayment `json:"payments"`
+	Extra UnknownFields `json:"-"` // SDK 未定义的字段
+	meta  *jsonMeta
 }
 
 // PaymentsHistoryResponse 支付历史响应
@@ -86,91 +74,30 @@ type PaymentsHistoryResponse struct {
 	Payments []Payment `json:"payments"`
 }
 
-func (raw rawPaymentsHistoryResponse) toPaymentsHistoryResponse() *PaymentsHistoryResponse {
-	var res PaymentsHistoryResponse
-	for _, rawPayment := range raw.Payments {
-		createdAt := parseTimePointer(rawPayment.CreatedAt)
-		res.Payments = append(res.Payments, Payment{
-			Amount:        rawPayment.Amount,
-			CreatedAt:     createdAt,
-			Currency:      rawPayment.Currency,
-			Last4:         rawPayment.Last4,
-			Network:       rawPayment.Network,
-			OneoffID:      rawPayment.OneoffID,
-			OrderID:       rawPayment.OrderID,
-			PaymentMethod: rawPayment.PaymentMethod,
-			Refunded:      rawPayment.Refunded,
-			SubsID:        rawPayment.SubsID,
-		})
+// timeErrors 返回所有支付记录中解析失败的时间字段
+func (r *PaymentsHistoryResponse) timeErrors() []error {
+	var errs []error
+	for i, p := range r.Payments {
+		errs = append(errs, prefixTimeErrors(fmt.Sprintf("payments[%d].", i), p.meta.timeErrors())...)
 	}
-	return &res
+	return errs
 }
 
 // TransactionReportRequest 获取所有交易请求
 type TransactionReportRequest struct {
-	LastTransactionDate string  `json:"last_transaction_date"` // 最后交易日期（RFC3339 格式）
-	SubsID              *string `json:"subs_id,omitempty"`     // 订阅ID（可选）
-	OrderID             *string `json:"order_id,omitempty"`    // 订单ID（可选）
-	OneoffID            *string `json:"oneoff_id,omitempty"`   // 一次性购买ID（可选）
-	Limit               *int    `json:"limit,omitempty"`       // 限制返回数量（可选，默认100，范围1-500）
-}
-
-// rawTransaction 原始交易信息（用于解析）
-type rawTransaction struct {
-	OrderID                             string  `json:"order_id"`
-	PPID                                int     `json:"pp_id"`
-	PPVersion                           int     `json:"pp_version"`
-	Status                              string  `json:"status"`
-	IsCIT                               bool    `json:"is_cit"`
-	IntegrationType                     string  `json:"integration_type"`
-	Region                              string  `json:"region"`
-	Amount                              string  `json:"amount"`
-	CurrencyCode                        string  `json:"currency_code"`
-	TrxID                               string  `json:"trx_id"`
-	PSP                                 string  `json:"psp"`
-	PSPTransactionID                    string  `json:"psp_transaction_id"`
-	AmountUSD                           string  `json:"amount_usd"`
-	IsFallback                          bool    `json:"is_fallback"`
-	PSPMerchantID                       string  `json:"psp_merchant_id"`
-	PSPTransactionType                  string  `json:"psp_transaction_type"`
-	PSPStatus                           string  `json:"psp_status"`
-	PSPDate                             string  `json:"psp_date"`
-	PSPCardTokenType                    *string `json:"psp_card_token_type"`
-	PSPReasonMessage                    *string `json:"psp_reason_message"`
-	PSPReasonType                       *string `json:"psp_reason_type"`
-	PSPReasonCode                       *string `json:"psp_reason_code"`
-	PSPReasonDeclineType                *string `json:"psp_reason_decline_type"`
-	TrxCreatedAt                        string  `json:"trx_created_at"`
-	MetaSubsID                          *string `json:"meta_subs_id"`
-	MetaSubsIteration                   *string `json:"meta_subs_iteration"`
-	MetaRetryStep                       *string `json:"meta_retry_step"`
-	MetaOneoffID                        *string `json:"meta_oneoff_id"`
-	MetaClientFFProjectID               *string `json:"meta_client_ff_project_id"`
-	MetaClientFFSessionID               *string `json:"meta_client_ff_session_id"`
-	MetaClientFFPriceID                 *string `json:"meta_client_ff_price_id"`
-	PMType                              string  `json:"pm_type"`
-	ThreeDSChallengeIssued              *bool   `json:"threeds_challenge_issued"`
-	ThreeDSProtocolVersion              *string `json:"threeds_protocol_version"`
-	ThreeDSResponseCode                 *string `json:"threeds_response_code"`
-	ThreeDSReasonCode                   *string `json:"threeds_reason_code"`
-	ThreeDSReasonText                   *string `json:"threeds_reason_text"`
-	AuthorizationType                   string  `json:"authorization_type"`
-	IsVaulted                           bool    `json:"is_vaulted"`
-	PMDataBinAccountFundingType         *string `json:"pm_data_bin_account_funding_type"`
-	PMDataBinAccountNumberType          *string `json:"pm_data_bin_account_number_type"`
-	PMDataBinIssuerName                 *string `json:"pm_data_bin_issuer_name"`
-	PMDataBinIssuerCountryCode          *string `json:"pm_data_bin_issuer_country_code"`
-	PMDataBinIssuerCurrencyCode         *string `json:"pm_data_bin_issuer_currency_code"`
-	Network                             *string `json:"network"`
-	PMDataBinPrepaidReloadableIndicator *string `json:"pm_data_bin_prepaid_reloadable_indicator"`
-	PMDataBinProductCode                *string `json:"pm_data_bin_product_code"`
-	PMDataBinProductName                *string `json:"pm_data_bin_product_name"`
-	PMDataBinProductUsageType           *string `json:"pm_data_bin_product_usage_type"`
-	PMDataBinRegionalRestriction        *string `json:"pm_data_bin_regional_restriction"`
-	PMDataExpirationDate                *string `json:"pm_data_expiration_date"`
-	PMDataFirst6                        *string `json:"pm_data_first6"`
-	PMDataLast4                         *string `json:"pm_data_last4"`
-	PMDataIsNetworkTokenized            *bool   `json:"pm_data_is_network_tokenized"`
+	LastTransactionDate time.Time `json:"last_transaction_date"` // 最后交易日期（以 UTC RFC3339 格式发送）
+	SubsID              *string   `json:"subs_id,omitempty"`     // 订阅ID（可选）
+	OrderID             *string   `json:"order_id,omitempty"`    // 订单ID（可选）
+	OneoffID            *string   `json:"oneoff_id,omitempty"`   // 一次性购买ID（可选）
+	Limit               *int      `json:"limit,omitempty"`       // 限制返回数量（可选，默认100，范围1-500）
+}
+
+func (r TransactionReportRequest) MarshalJSON() ([]byte, error) {
+	type alias TransactionReportRequest
+	return json.Marshal(struct {
+		alias
+		LastTransactionDate string `json:"last_transaction_date"`
+	}{alias(r), formatRequestTime(r.LastTransactionDate)})
 }
 
 // Transaction 交易信息
@@ -229,11 +156,9 @@ type Transaction struct {
 	PMDataFirst6                        *string    `json:"pm_data_first6"`
 	PMDataLast4                         *string    `json:"pm_data_last4"`
 	PMDataIsNetworkTokenized            *bool      `json:"pm_data_is_network_tokenized"`
-}
 
-// rawTransactionReportResponse 原始交易报告响应
-type rawTransactionReportResponse struct {
-	Transactions []rawTransaction `json:"transactions"`
+	Extra UnknownFields `json:"-"` // SDK 未定义的字段
+	meta  *jsonMeta
 }
 
 // TransactionReportResponse 交易报告响应
@@ -241,69 +166,13 @@ type TransactionReportResponse struct {
 	Transactions []Transaction `json:"transactions"`
 }
 
-func (raw rawTransactionReportResponse) toTransactionReportResponse() *TransactionReportResponse {
-	var res TransactionReportResponse
-	for _, rawTx := range raw.Transactions {
-		pspDate := parseTimePointer(rawTx.PSPDate)
-		trxCreatedAt := parseTimePointer(rawTx.TrxCreatedAt)
-		res.Transactions = append(res.Transactions, Transaction{
-			OrderID:                             rawTx.OrderID,
-			PPID:                                rawTx.PPID,
-			PPVersion:                           rawTx.PPVersion,
-			Status:                              rawTx.Status,
-			IsCIT:                               rawTx.IsCIT,
-			IntegrationType:                     rawTx.IntegrationType,
-			Region:                              rawTx.Region,
-			Amount:                              rawTx.Amount,
-			CurrencyCode:                        rawTx.CurrencyCode,
-			TrxID:                               rawTx.TrxID,
-			PSP:                                 rawTx.PSP,
-			PSPTransactionID:                    rawTx.PSPTransactionID,
-			AmountUSD:                           rawTx.AmountUSD,
-			IsFallback:                          rawTx.IsFallback,
-			PSPMerchantID:                       rawTx.PSPMerchantID,
-			PSPTransactionType:                  rawTx.PSPTransactionType,
-			PSPStatus:                           rawTx.PSPStatus,
-			PSPDate:                             pspDate,
-			PSPCardTokenType:                    rawTx.PSPCardTokenType,
-			PSPReasonMessage:                    rawTx.PSPReasonMessage,
-			PSPReasonType:                       rawTx.PSPReasonType,
-			PSPReasonCode:                       rawTx.PSPReasonCode,
-			PSPReasonDeclineType:                rawTx.PSPReasonDeclineType,
-			TrxCreatedAt:                        trxCreatedAt,
-			MetaSubsID:                          rawTx.MetaSubsID,
-			MetaSubsIteration:                   rawTx.MetaSubsIteration,
-			MetaRetryStep:                       rawTx.MetaRetryStep,
-			MetaOneoffID:                        rawTx.MetaOneoffID,
-			MetaClientFFProjectID:               rawTx.MetaClientFFProjectID,
-			MetaClientFFSessionID:               rawTx.MetaClientFFSessionID,
-			MetaClientFFPriceID:                 rawTx.MetaClientFFPriceID,
-			PMType:                              rawTx.PMType,
-			ThreeDSChallengeIssued:              rawTx.ThreeDSChallengeIssued,
-			ThreeDSProtocolVersion:              rawTx.ThreeDSProtocolVersion,
-			ThreeDSResponseCode:                 rawTx.ThreeDSResponseCode,
-			ThreeDSReasonCode:                   rawTx.ThreeDSReasonCode,
-			ThreeDSReasonText:                   rawTx.ThreeDSReasonText,
-			AuthorizationType:                   rawTx.AuthorizationType,
-			IsVaulted:                           rawTx.IsVaulted,
-			PMDataBinAccountFundingType:         rawTx.PMDataBinAccountFundingType,
-			PMDataBinAccountNumberType:          rawTx.PMDataBinAccountNumberType,
-			PMDataBinIssuerName:                 rawTx.PMDataBinIssuerName,
-			PMDataBinIssuerCountryCode:          rawTx.PMDataBinIssuerCountryCode,
-			PMDataBinIssuerCurrencyCode:         rawTx.PMDataBinIssuerCurrencyCode,
-			Network:                             rawTx.Network,
-			PMDataBinPrepaidReloadableIndicator: rawTx.PMDataBinPrepaidReloadableIndicator,
-			PMDataBinProductCode:                rawTx.PMDataBinProductCode,
-			PMDataBinProductName:                rawTx.PMDataBinProductName,
-			PMDataBinProductUsageType:           rawTx.PMDataBinProductUsageType,
-			PMDataBinRegionalRestriction:        rawTx.PMDataBinRegionalRestriction,
-			PMDataExpirationDate:                rawTx.PMDataExpirationDate,
-			PMDataFirst6:                        rawTx.PMDataFirst6,
-			PMDataLast4:                         rawTx.PMDataLast4,
-			PMDataIsNetworkTokenized:            rawTx.PMDataIsNetworkTokenized,
-		})
+// timeErrors 返回所有交易中解析失败的时间字段
+func (r *TransactionReportResponse) timeErrors() []error {
+	var errs []error
+	for i, t := range r.Transactions {
+		errs = append(errs, prefixTimeErrors(fmt.Sprintf("transactions[%d].", i), t.meta.timeErrors())...)
 	}
-	return &res
+	return errs
 }
 
 // ===== Subscription Management =====
@@ -381,20 +250,36 @@ type DiscountRequest struct {
 
 // SubscriptionDeferRequest 延迟订阅请求
 type SubscriptionDeferRequest struct {
-	ExternalID string  `json:"external_id"`
-	SubsID     string  `json:"subs_id"`
-	DeferTill  string  `json:"defer_till"`
-	Reason     *string `json:"reason,omitempty"`
-	Comment    *string `json:"comment,omitempty"`
+	ExternalID string    `json:"external_id"`
+	SubsID     string    `json:"subs_id"`
+	DeferTill  time.Time `json:"defer_till"` // 延迟到的时间，以 UTC RFC3339 格式发送
+	Reason     *string   `json:"reason,omitempty"`
+	Comment    *string   `json:"comment,omitempty"`
+}
+
+func (r SubscriptionDeferRequest) MarshalJSON() ([]byte, error) {
+	type alias SubscriptionDeferRequest
+	return json.Marshal(struct {
+		alias
+		DeferTill string `json:"defer_till"`
+	}{alias(r), formatRequestTime(r.DeferTill)})
 }
 
 // SubscriptionPauseRequest 暂停订阅请求
 type SubscriptionPauseRequest struct {
-	ExternalID string  `json:"external_id"`
-	SubsID     string  `json:"subs_id"`
-	PauseTill  string  `json:"pause_till"`
-	Reason     *string `json:"reason,omitempty"`
-	Comment    *string `json:"comment,omitempty"`
+	ExternalID string    `json:"external_id"`
+	SubsID     string    `json:"subs_id"`
+	PauseTill  time.Time `json:"pause_till"` // 暂停到的时间，以 UTC RFC3339 格式发送
+	Reason     *string   `json:"reason,omitempty"`
+	Comment    *string   `json:"comment,omitempty"`
+}
+
+func (r SubscriptionPauseRequest) MarshalJSON() ([]byte, error) {
+	type alias SubscriptionPauseRequest
+	return json.Marshal(struct {
+		alias
+		PauseTill string `json:"pause_till"`
+	}{alias(r), formatRequestTime(r.PauseTill)})
 }
 
 // SubscriptionResumeRequest 恢复订阅请求
@@ -453,11 +338,17 @@ type Currency struct {
 	MinorUnits int    `json:"minor_units"` // 小数位数
 	Title      string `json:"title"`       // 货币名称
 	Symbol     string `json:"symbol"`      // 货币符号
+
+	Extra UnknownFields `json:"-"` // SDK 未定义的字段
+	meta  *jsonMeta
 }
 
 // Feature 功能特性
 type Feature struct {
 	Ident string `json:"ident"`
+
+	Extra UnknownFields `json:"-"` // SDK 未定义的字段
+	meta  *jsonMeta
 }
 
 type IntroType string
@@ -480,6 +371,7 @@ const (
 
 // PricePoint 价格点
 type PricePoint struct {
+	ID                           *int                `json:"id,omitempty"` // 价格点ID，对应 Transaction.PPID；API 文档未列出该字段，响应中可能没有
 	Ident                        string              `json:"ident"`
 	Currency                     Currency            `json:"currency"`
 	IntroType                    IntroType           `json:"intro_type"`
@@ -493,6 +385,9 @@ type PricePoint struct {
 	NextPrice                    *string             `json:"next_price"`
 	NextPeriod                   *int                `json:"next_period"`
 	NextPeriodDuration           *PeriodDurationUnit `json:"next_period_duration"`
+
+	Extra UnknownFields `json:"-"` // SDK 未定义的字段
+	meta  *jsonMeta
 }
 
 // PricePointsListResponse 价格点列表响应
@@ -508,13 +403,13 @@ type MyAssetsRequest struct {
 }
 
 type SubscriptionField struct {
-	SubsID               string         `json:"subs_id"`
-	IsActive             bool           `json:"is_active"`
-	PricePoint           PricePoint     `json:"price_point"`
-	Status               []string       `json:"status"`
-	AvailableActions     []string       `json:"available_actions"`
-	InitialOrderMetadata map[string]any `json:"initial_order_metadata"`
-	Iteration            int            `json:"iteration"`
+	SubsID               string               `json:"subs_id"`
+	IsActive             bool                 `json:"is_active"`
+	PricePoint           PricePoint           `json:"price_point"`
+	Status               []SubscriptionStatus `json:"status"`
+	AvailableActions     []SubscriptionAction `json:"available_actions"`
+	InitialOrderMetadata map[string]any       `json:"initial_order_metadata"`
+	Iteration            int                  `json:"iteration"`
 }
 
 // rawSubscription 订阅信息
@@ -532,6 +427,9 @@ type Subscription struct {
 	CurrentPeriodStartsAt *time.Time `json:"current_period_starts_at"`
 	CurrentPeriodEndsAt   *time.Time `json:"current_period_ends_at"`
 	NextCheckAt           *time.Time `json:"next_check_at"`
+
+	Extra UnknownFields `json:"-"` // SDK 未定义的字段
+	meta  *jsonMeta
 }
 
 type OneoffField struct {
@@ -553,12 +451,9 @@ type OneOffPurchase struct {
 	OneoffField `json:",inline"`
 	StartedAt   *time.Time `json:"started_at"`
 	RevokedAt   *time.Time `json:"revoked_at"`
-}
 
-// rawMyAssetsResponse 用户资产响应
-type rawMyAssetsResponse struct {
-	Subscriptions   []rawSubscription   `json:"subscriptions"`
-	OneOffPurchases []rawOneOffPurchase `json:"oneoffs"`
+	Extra UnknownFields `json:"-"` // SDK 未定义的字段
+	meta  *jsonMeta
 }
 
 type MyAssetsResponse struct {
@@ -566,49 +461,24 @@ type MyAssetsResponse struct {
 	OneOffPurchases []OneOffPurchase `json:"oneoffs"`
 }
 
-func parseTimePointer(s string) *time.Time {
-	if s == "" {
-		return nil
-	}
-	t, err := time.Parse(timeFormat, s)
-	if err == nil {
-		return &t
-	}
-	bs, err := json.Marshal(s)
-	if err != nil {
-		return nil
-	}
-	var t1 time.Time
-	err = json.Unmarshal(bs, &t1)
-	return &t1
-}
-
-func (raw rawMyAssetsResponse) toMyAssetsResponse() *MyAssetsResponse {
-	var res MyAssetsResponse
-	for _, rawSub := range raw.Subscriptions {
-		startedAt := parseTimePointer(rawSub.StartedAt)
-		currStart := parseTimePointer(rawSub.CurrentPeriodStartsAt)
-		currEnd := parseTimePointer(rawSub.CurrentPeriodEndsAt)
-		nextCheck := parseTimePointer(rawSub.NextCheckAt)
-		res.Subscriptions = append(res.Subscriptions, Subscription{
-			SubscriptionField:     rawSub.SubscriptionField,
-			StartedAt:             startedAt,
-			CurrentPeriodStartsAt: currStart,
-			CurrentPeriodEndsAt:   currEnd,
-			NextCheckAt:           nextCheck,
-		})
+// formatRequestTime 将请求中的时间转换为 UTC 并按 requestTimeFormat 格式化，零值返回空字符串
+func formatRequestTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
 	}
+	return t.UTC().Format(requestTimeFormat)
+}
 
-	for _, rawOneoff := range raw.OneOffPurchases {
-		startedAt := parseTimePointer(rawOneoff.StartedAt)
-		revokedAt := parseTimePointer(rawOneoff.RevokedAt)
-		res.OneOffPurchases = append(res.OneOffPurchases, OneOffPurchase{
-			OneoffField: rawOneoff.OneoffField,
-			StartedAt:   startedAt,
-			RevokedAt:   revokedAt,
-		})
+// timeErrors 返回所有订阅和一次性购买中解析失败的时间字段
+func (r *MyAssetsResponse) timeErrors() []error {
+	var errs []error
+	for i, sub := range r.Subscriptions {
+		errs = append(errs, prefixTimeErrors(fmt.Sprintf("subscriptions[%d].", i), sub.meta.timeErrors())...)
+	}
+	for i, oneoff := range r.OneOffPurchases {
+		errs = append(errs, prefixTimeErrors(fmt.Sprintf("oneoffs[%d].", i), oneoff.meta.timeErrors())...)
 	}
-	return &res
+	return errs
 }
 
 type OrderStatus string
@@ -643,6 +513,9 @@ type rawOrder struct {
 type Order struct {
 	OrderField `json:",inline"`
 	CreatedAt  *time.Time `json:"created_at"`
+
+	Extra UnknownFields `json:"-"` // SDK 未定义的字段
+	meta  *jsonMeta
 }
 
 type EventType string
@@ -654,6 +527,8 @@ const (
 	EventTypeOneoff       EventType = "oneoff"
 )
 
+// EventSubtype 事件子类型，不同事件类型的子类型取值可能相同，
+// 建议通过 Event.Payload 或 AsSubscription/AsOrder/AsOneoff/AsRefund 使用各自的子类型
 type EventSubtype string
 
 const (
@@ -679,6 +554,7 @@ const (
 	EventSubtypeOneoffGranted EventSubtype = "granted"
 	EventSubtypeOneoffRevoked EventSubtype = "revoked"
 
+	// Deprecated: 与 EventSubtypeOrderSettled 取值相同，无法区分事件类型，请使用 RefundSubtypeSettled 和 Event.AsRefund
 	EventSubtypeRefundSettled EventSubtype = "settled"
 )
 
@@ -698,6 +574,9 @@ type Event struct {
 	Subscription *Subscription   `json:"subscription"`
 	Order        *Order          `json:"order"`
 	Oneoff       *OneOffPurchase `json:"oneoff"`
+
+	Extra UnknownFields `json:"-"` // SDK 未定义的字段
+	meta  *jsonMeta
 }
 
 type RefundInfoField struct {
@@ -729,76 +608,23 @@ type rawEvent struct {
 		Email      string `json:"email"`
 		ExternalID string `json:"external_id"`
 	} `json:"user"`
-	Subscription *rawSubscription   `json:"subscription"`
-	Order        *rawOrder          `json:"order"`
-	Oneoff       *rawOneOffPurchase `json:"oneoff"`
-}
-
-// parseEventTimestamp 解析事件时间戳
-func parseEventTimestamp(s string) (time.Time, error) {
-	t, err := time.Parse(timeFormat, s)
-	if err == nil {
-		return t, nil
-	}
-	bs, err := json.Marshal(s)
-	if err != nil {
-		return time.Time{}, err
-	}
-	var t1 time.Time
-	err = json.Unmarshal(bs, &t1)
-	return t1, err
+	Subscription *Subscription   `json:"subscription"`
+	Order        *Order          `json:"order"`
+	Oneoff       *OneOffPurchase `json:"oneoff"`
 }
 
-func ParseEvent(data []byte) (*Event, error) {
-	var raw rawEvent
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, err
-	}
-
-	event := Event{
-		EventID:    raw.EventID,
-		EventType:  raw.EventType,
-		Subtype:    raw.Subtype,
-		ExternalID: raw.ExternalID,
-		IsLivemode: raw.IsLivemode,
-		User:       raw.User,
-	}
-
-	eventTimestamp, err := parseEventTimestamp(raw.EventTimestamp)
-	if err != nil {
-		return nil, err
+// TimeErrors 返回事件及嵌套对象中解析失败的时间字段（*TimeParseError）
+// 非严格模式下这些字段被置为 nil，调用方可以据此判断哪些时间不可用
+func (e *Event) TimeErrors() []error {
+	errs := append([]error(nil), e.meta.timeErrors()...)
+	if e.Subscription != nil {
+		errs = append(errs, prefixTimeErrors("subscription.", e.Subscription.meta.timeErrors())...)
 	}
-	event.EventTimestamp = eventTimestamp
-
-	// 转换 rawSubscription 为 Subscription
-	if raw.Subscription != nil {
-		event.Subscription = &Subscription{
-			SubscriptionField:     raw.Subscription.SubscriptionField,
-			StartedAt:             parseTimePointer(raw.Subscription.StartedAt),
-			CurrentPeriodStartsAt: parseTimePointer(raw.Subscription.CurrentPeriodStartsAt),
-			CurrentPeriodEndsAt:   parseTimePointer(raw.Subscription.CurrentPeriodEndsAt),
-			NextCheckAt:           parseTimePointer(raw.Subscription.NextCheckAt),
-		}
+	if e.Order != nil {
+		errs = append(errs, prefixTimeErrors("order.", e.Order.meta.timeErrors())...)
 	}
-	if raw.Order != nil {
-		event.Order = &Order{
-			OrderField: raw.Order.OrderField,
-			CreatedAt:  parseTimePointer(raw.Order.CreatedAt),
-		}
+	if e.Oneoff != nil {
+		errs = append(errs, prefixTimeErrors("oneoff.", e.Oneoff.meta.timeErrors())...)
 	}
-	if raw.Oneoff != nil {
-		event.Oneoff = &OneOffPurchase{
-			OneoffField: raw.Oneoff.OneoffField,
-			StartedAt:   parseTimePointer(raw.Oneoff.StartedAt),
-			RevokedAt:   parseTimePointer(raw.Oneoff.RevokedAt),
-		}
-	}
-	if raw.RawRefundInfo != nil {
-		event.RefundInfo = &RefundInfo{
-			RefundInfoField: raw.RawRefundInfo.RefundInfoField,
-			CreatedAt:       parseTimePointer(raw.RawRefundInfo.CreatedAt),
-		}
-	}
-
-	return &event, nil
+	return errs
 }
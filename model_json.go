@@ -0,0 +1,294 @@
+package funnelfox
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// 以下模型解码时保留原始 JSON（键顺序、原始值、未知字段），
+// 重新编码时未修改的部分与 FunnelFox 发送的内容保持一致，便于存储和转发
+
+func (c *Currency) UnmarshalJSON(data []byte) error {
+	type alias Currency
+	var a alias
+	obj, err := decodeObject(data, &a)
+	if err != nil || obj == nil {
+		return err
+	}
+	a.Extra = obj.extra(knownJSONKeys(reflect.TypeOf(a)))
+	a.meta = &jsonMeta{raw: obj}
+	*c = Currency(a)
+	return nil
+}
+
+func (c Currency) MarshalJSON() ([]byte, error) {
+	type alias Currency
+	return marshalPreserving(alias(c), c.Extra, c.meta.object())
+}
+
+func (f *Feature) UnmarshalJSON(data []byte) error {
+	type alias Feature
+	var a alias
+	obj, err := decodeObject(data, &a)
+	if err != nil || obj == nil {
+		return err
+	}
+	a.Extra = obj.extra(knownJSONKeys(reflect.TypeOf(a)))
+	a.meta = &jsonMeta{raw: obj}
+	*f = Feature(a)
+	return nil
+}
+
+func (f Feature) MarshalJSON() ([]byte, error) {
+	type alias Feature
+	return marshalPreserving(alias(f), f.Extra, f.meta.object())
+}
+
+func (p *PricePoint) UnmarshalJSON(data []byte) error {
+	type alias PricePoint
+	var a alias
+	obj, err := decodeObject(data, &a)
+	if err != nil || obj == nil {
+		return err
+	}
+	a.Extra = obj.extra(knownJSONKeys(reflect.TypeOf(a)))
+	a.meta = &jsonMeta{raw: obj}
+	*p = PricePoint(a)
+	return nil
+}
+
+func (p PricePoint) MarshalJSON() ([]byte, error) {
+	type alias PricePoint
+	return marshalPreserving(alias(p), p.Extra, p.meta.object())
+}
+
+func (s *Subscription) UnmarshalJSON(data []byte) error {
+	var raw rawSubscription
+	obj, err := decodeObject(data, &raw)
+	if err != nil || obj == nil {
+		return err
+	}
+	d := &timeDecoder{}
+	*s = Subscription{
+		SubscriptionField:     raw.SubscriptionField,
+		StartedAt:             d.parse("started_at", raw.StartedAt),
+		CurrentPeriodStartsAt: d.parse("current_period_starts_at", raw.CurrentPeriodStartsAt),
+		CurrentPeriodEndsAt:   d.parse("current_period_ends_at", raw.CurrentPeriodEndsAt),
+		NextCheckAt:           d.parse("next_check_at", raw.NextCheckAt),
+		Extra:                 obj.extra(knownJSONKeys(reflect.TypeOf(raw))),
+		meta:                  &jsonMeta{raw: obj, timeErrs: d.errs},
+	}
+	return nil
+}
+
+func (s Subscription) MarshalJSON() ([]byte, error) {
+	type alias Subscription
+	return marshalPreserving(alias(s), s.Extra, s.meta.object())
+}
+
+func (o *OneOffPurchase) UnmarshalJSON(data []byte) error {
+	var raw rawOneOffPurchase
+	obj, err := decodeObject(data, &raw)
+	if err != nil || obj == nil {
+		return err
+	}
+	d := &timeDecoder{}
+	*o = OneOffPurchase{
+		OneoffField: raw.OneoffField,
+		StartedAt:   d.parse("started_at", raw.StartedAt),
+		RevokedAt:   d.parse("revoked_at", raw.RevokedAt),
+		Extra:       obj.extra(knownJSONKeys(reflect.TypeOf(raw))),
+		meta:        &jsonMeta{raw: obj, timeErrs: d.errs},
+	}
+	return nil
+}
+
+func (o OneOffPurchase) MarshalJSON() ([]byte, error) {
+	type alias OneOffPurchase
+	return marshalPreserving(alias(o), o.Extra, o.meta.object())
+}
+
+func (o *Order) UnmarshalJSON(data []byte) error {
+	var raw rawOrder
+	obj, err := decodeObject(data, &raw)
+	if err != nil || obj == nil {
+		return err
+	}
+	d := &timeDecoder{}
+	*o = Order{
+		OrderField: raw.OrderField,
+		CreatedAt:  d.parse("created_at", raw.CreatedAt),
+		Extra:      obj.extra(knownJSONKeys(reflect.TypeOf(raw))),
+		meta:       &jsonMeta{raw: obj, timeErrs: d.errs},
+	}
+	return nil
+}
+
+func (o Order) MarshalJSON() ([]byte, error) {
+	type alias Order
+	return marshalPreserving(alias(o), o.Extra, o.meta.object())
+}
+
+// UnmarshalJSON 解析事件，event_timestamp 无法解析时返回 *TimeParseError
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw rawEvent
+	obj, err := decodeObject(data, &raw)
+	if err != nil || obj == nil {
+		return err
+	}
+	eventTimestamp, err := parseTime(raw.EventTimestamp)
+	if err != nil {
+		return &TimeParseError{Field: "event_timestamp", Value: raw.EventTimestamp, Err: err}
+	}
+
+	d := &timeDecoder{}
+	event := Event{
+		EventID:        raw.EventID,
+		EventTimestamp: eventTimestamp,
+		EventType:      raw.EventType,
+		Subtype:        raw.Subtype,
+		ExternalID:     raw.ExternalID,
+		IsLivemode:     raw.IsLivemode,
+		User:           raw.User,
+		Subscription:   raw.Subscription,
+		Order:          raw.Order,
+		Oneoff:         raw.Oneoff,
+	}
+	// 只有退款事件的顶层 order_id、currency_code 等字段才是退款信息
+	if raw.EventType == EventTypeRefund {
+		if event.RefundInfo, err = decodeRefundInfo(obj, raw.RawRefundInfo, d); err != nil {
+			return err
+		}
+	}
+	known := knownJSONKeys(reflect.TypeOf(raw))
+	if _, nested := obj.values["refund"]; event.RefundInfo == nil || nested {
+		// 非退款事件、以及退款信息来自嵌套 refund 对象时，与退款字段同名的顶层字段作为未知字段保留
+		for k := range knownJSONKeys(reflect.TypeOf(RawRefundInfo{})) {
+			delete(known, k)
+		}
+	}
+	event.Extra = obj.extra(known)
+	event.meta = &jsonMeta{raw: obj, timeErrs: d.errs}
+	*e = event
+	return nil
+}
+
+// MarshalJSON 编码事件，退款信息来自嵌套 refund 对象时重新编码回 refund 对象，顶层同名字段从 Extra 原样输出
+func (e Event) MarshalJSON() ([]byte, error) {
+	type alias Event
+	a := alias(e)
+	extra := e.Extra
+	if nestedRaw, nested := e.Extra.Get("refund"); nested && e.meta.object() != nil {
+		a.RefundInfo = nil
+		if e.RefundInfo != nil {
+			refund, err := marshalNestedRefund(*e.RefundInfo, nestedRaw)
+			if err != nil {
+				return nil, err
+			}
+			extra.Set("refund", refund)
+		}
+	}
+	return marshalPreserving(a, extra, e.meta.object())
+}
+
+// ParseEvent 解析 webhook 事件，event_timestamp 无法解析时返回错误，其他时间字段无法解析时置为 nil，
+// 解析问题通过 Event.TimeErrors 获取。不记录指标和日志，需要时使用 Client.ParseEvent
+func ParseEvent(data []byte) (*Event, error) {
+	return parseEvent(data, false)
+}
+
+// ParseEventStrict 解析 webhook 事件，任何时间字段无法解析都返回 *TimeParseError
+// 不记录指标和日志，需要时使用 Client.ParseEvent
+func ParseEventStrict(data []byte) (*Event, error) {
+	return parseEvent(data, true)
+}
+
+func parseEvent(data []byte, strict bool) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	if strict {
+		if err := errors.Join(event.TimeErrors()...); err != nil {
+			return nil, err
+		}
+	}
+	return &event, nil
+}
+
+// ParseEvent 按客户端配置解析 webhook 事件（SetStrictDecoding 决定时间字段的解析模式），
+// 解析成功时记录事件指标，并记录未知字段和无法解析的时间字段日志
+func (c *Client) ParseEvent(data []byte) (*Event, error) {
+	event, err := parseEvent(data, c.strictDecoding)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkDecode("webhook", event.TimeErrors()); err != nil {
+		return nil, err
+	}
+	c.metrics.IncEvent(event.EventType, event.Subtype)
+	unknown := make(unknownFields)
+	unknown.addEvent(event)
+	c.logUnknownFields("webhook", unknown)
+	return event, nil
+}
+
+// marshalNestedRefund 按原始 refund 对象 orig 的形式编码退款信息，保留其中的键顺序和未知字段
+func marshalNestedRefund(info RefundInfo, orig json.RawMessage) (json.RawMessage, error) {
+	// 原始值不是对象时按字段声明顺序编码
+	obj, _ := parseJSONObject(orig)
+	var extra UnknownFields
+	if obj != nil {
+		extra = obj.extra(knownJSONKeys(reflect.TypeOf(info)))
+	}
+	return marshalPreserving(info, extra, obj)
+}
+
+func (p *Payment) UnmarshalJSON(data []byte) error {
+	type alias Payment
+	var raw struct {
+		alias
+		CreatedAt string `json:"created_at"`
+	}
+	obj, err := decodeObject(data, &raw)
+	if err != nil || obj == nil {
+		return err
+	}
+	d := &timeDecoder{}
+	*p = Payment(raw.alias)
+	p.CreatedAt = d.parse("created_at", raw.CreatedAt)
+	p.Extra = obj.extra(knownJSONKeys(reflect.TypeOf(raw.alias)))
+	p.meta = &jsonMeta{raw: obj, timeErrs: d.errs}
+	return nil
+}
+
+func (p Payment) MarshalJSON() ([]byte, error) {
+	type alias Payment
+	return marshalPreserving(alias(p), p.Extra, p.meta.object())
+}
+
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	type alias Transaction
+	var raw struct {
+		alias
+		PSPDate      string `json:"psp_date"`
+		TrxCreatedAt string `json:"trx_created_at"`
+	}
+	obj, err := decodeObject(data, &raw)
+	if err != nil || obj == nil {
+		return err
+	}
+	d := &timeDecoder{}
+	*t = Transaction(raw.alias)
+	t.PSPDate = d.parse("psp_date", raw.PSPDate)
+	t.TrxCreatedAt = d.parse("trx_created_at", raw.TrxCreatedAt)
+	t.Extra = obj.extra(knownJSONKeys(reflect.TypeOf(raw.alias)))
+	t.meta = &jsonMeta{raw: obj, timeErrs: d.errs}
+	return nil
+}
+
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	type alias Transaction
+	return marshalPreserving(alias(t), t.Extra, t.meta.object())
+}
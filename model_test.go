@@ -0,0 +1,55 @@
+package funnelfox
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRequestTimeFormat(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	at := time.Date(2024, 3, 1, 8, 30, 0, 123456789, shanghai)
+	want := "2024-03-01T00:30:00.123456Z"
+
+	midnight := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		req   any
+		field string
+		at    time.Time
+		want  string
+	}{
+		{"defer", SubscriptionDeferRequest{ExternalID: "u1", SubsID: "s1", DeferTill: at}, "defer_till", at, want},
+		{"pause", SubscriptionPauseRequest{ExternalID: "u1", SubsID: "s1", PauseTill: at}, "pause_till", at, want},
+		{"transaction report", TransactionReportRequest{LastTransactionDate: at}, "last_transaction_date", at, want},
+		{"whole seconds", SubscriptionPauseRequest{PauseTill: midnight}, "pause_till", midnight, "2024-03-01T00:00:00Z"},
+		{"zero", TransactionReportRequest{}, "last_transaction_date", time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, err := json.Marshal(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var m map[string]any
+			if err := json.Unmarshal(bs, &m); err != nil {
+				t.Fatal(err)
+			}
+			if m[tt.field] != tt.want {
+				t.Errorf("%s = %v, want %q", tt.field, m[tt.field], tt.want)
+			}
+			if tt.want == "" {
+				return
+			}
+			// 发送的时间能按响应格式解析回同一时刻
+			parsed, err := parseTime(tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !parsed.Equal(tt.at.Truncate(time.Microsecond)) {
+				t.Errorf("parsed = %v, want %v", parsed, tt.at)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package funnelfox
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OrderedDispatcher 按用户顺序处理 webhook 事件
+//
+// 同一外部用户ID的事件串行处理，不同用户之间并行处理。
+// 每个事件到达后最多等待 window，期间到达的同一用户事件按 EventTimestamp 重新排序；
+// window 为 0 时不等待，只对处理前一事件期间排队的事件排序。
+// 没有外部用户ID的事件直接处理。
+type OrderedDispatcher struct {
+	handle func(ctx context.Context, event *Event) error
+	window time.Duration
+	logger Logger
+
+	mu     sync.Mutex
+	seq    uint64
+	queues map[string]*orderedQueue
+}
+
+type orderedItem struct {
+	ctx     context.Context
+	event   *Event
+	arrived time.Time
+	seq     uint64
+	done    chan error
+	started bool
+}
+
+// orderedQueue 单个用户的待处理事件，按 (EventTimestamp, 到达顺序) 排序
+type orderedQueue struct {
+	items []*orderedItem
+	last  time.Time // 最近处理的事件时间
+	wake  chan struct{}
+}
+
+// NewOrderedDispatcher 创建 OrderedDispatcher
+// handle: 事件处理函数，可以配合 DispatchEvent 使用
+// window: 重新排序窗口
+// logger: 日志记录器，可以为 nil（使用 NopLogger）
+func NewOrderedDispatcher(handle func(ctx context.Context, event *Event) error, window time.Duration, logger Logger) *OrderedDispatcher {
+	if logger == nil {
+		logger = &NopLogger{}
+	}
+	if window < 0 {
+		window = 0
+	}
+	return &OrderedDispatcher{
+		handle: handle,
+		window: window,
+		logger: logger,
+		queues: make(map[string]*orderedQueue),
+	}
+}
+
+// eventUserKey 返回事件所属用户的外部ID
+func eventUserKey(event *Event) string {
+	if event.ExternalID != nil && *event.ExternalID != "" {
+		return *event.ExternalID
+	}
+	return event.User.ExternalID
+}
+
+// Dispatch 将事件放入所属用户的队列，阻塞直到事件处理完成并返回处理结果
+// ctx 在事件开始处理前结束时，事件被丢弃并返回 ctx.Err()
+// 签名与 HandleEvent 的处理函数一致，可以直接作为处理函数使用
+func (d *OrderedDispatcher) Dispatch(ctx context.Context, event *Event) error {
+	key := eventUserKey(event)
+	if key == "" {
+		return d.handle(ctx, event)
+	}
+
+	d.mu.Lock()
+	d.seq++
+	item := &orderedItem{
+		ctx:     ctx,
+		event:   event,
+		arrived: time.Now(),
+		seq:     d.seq,
+		done:    make(chan error, 1),
+	}
+	q, ok := d.queues[key]
+	if !ok {
+		q = &orderedQueue{wake: make(chan struct{}, 1)}
+		d.queues[key] = q
+		go d.run(key, q)
+	}
+	q.insert(item)
+	d.mu.Unlock()
+	q.notify()
+
+	select {
+	case err := <-item.done:
+		return err
+	case <-ctx.Done():
+		d.mu.Lock()
+		started := item.started
+		if !started {
+			// 立即移出队列，Pending 不再计入被取消的事件
+			q.remove(item)
+		}
+		d.mu.Unlock()
+		if !started {
+			// 唤醒队列，重新计算等待时间或在队列为空时退出
+			q.notify()
+			return ctx.Err()
+		}
+		return <-item.done
+	}
+}
+
+// Pending 返回排队中（未开始处理）的事件数量，Dispatch 因 ctx 结束返回前事件已移出队列
+func (d *OrderedDispatcher) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for _, q := range d.queues {
+		n += len(q.items)
+	}
+	return n
+}
+
+// run 依次处理单个用户的事件，队列为空时退出
+func (d *OrderedDispatcher) run(key string, q *orderedQueue) {
+	timer := time.NewTimer(d.window)
+	defer timer.Stop()
+	for {
+		d.mu.Lock()
+		if len(q.items) == 0 {
+			delete(d.queues, key)
+			d.mu.Unlock()
+			return
+		}
+		head := q.items[0]
+		if wait := time.Until(head.arrived.Add(d.window)); wait > 0 {
+			d.mu.Unlock()
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-q.wake:
+			}
+			continue
+		}
+		q.items = q.items[1:]
+		head.started = true
+		ts := head.event.EventTimestamp
+		late := ts.Before(q.last)
+		if !late {
+			q.last = ts
+		}
+		d.mu.Unlock()
+
+		if late {
+			d.logger.Info("funnelfox_event_out_of_order",
+				String("external_id", key),
+				String("event_id", head.event.EventID),
+				String("event_timestamp", ts.Format(time.RFC3339Nano)),
+				String("last_timestamp", q.last.Format(time.RFC3339Nano)))
+		}
+		head.done <- d.handle(head.ctx, head.event)
+	}
+}
+
+func (q *orderedQueue) insert(item *orderedItem) {
+	i := sort.Search(len(q.items), func(i int) bool {
+		other := q.items[i]
+		if !other.event.EventTimestamp.Equal(item.event.EventTimestamp) {
+			return other.event.EventTimestamp.After(item.event.EventTimestamp)
+		}
+		return other.seq > item.seq
+	})
+	q.items = append(q.items, nil)
+	copy(q.items[i+1:], q.items[i:])
+	q.items[i] = item
+}
+
+func (q *orderedQueue) remove(item *orderedItem) {
+	for i, other := range q.items {
+		if other == item {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *orderedQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
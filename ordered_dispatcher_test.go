@@ -0,0 +1,277 @@
+package funnelfox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func orderedEvent(id, user string, at time.Duration) *Event {
+	e := &Event{EventID: id, EventTimestamp: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Add(at)}
+	e.User.ExternalID = user
+	return e
+}
+
+// handledOrder 记录处理顺序
+type handledOrder struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (h *handledOrder) handle(ctx context.Context, e *Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ids = append(h.ids, e.EventID)
+	return nil
+}
+
+func (h *handledOrder) got() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.ids...)
+}
+
+// dispatchStaggered 按顺序并发提交事件，相邻事件间隔 gap，等待全部处理完成
+func dispatchStaggered(t *testing.T, d *OrderedDispatcher, events []*Event, gap time.Duration) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for _, e := range events {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.Dispatch(context.Background(), e); err != nil {
+				t.Errorf("Dispatch(%s) = %v", e.EventID, err)
+			}
+		}()
+		time.Sleep(gap)
+	}
+	wg.Wait()
+}
+
+func TestOrderedDispatcherOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		events []*Event
+		want   []string
+	}{
+		{
+			name:   "reordered within window",
+			window: 200 * time.Millisecond,
+			events: []*Event{
+				orderedEvent("unsubscription", "u1", 2*time.Second),
+				orderedEvent("renewing", "u1", time.Second),
+			},
+			want: []string{"renewing", "unsubscription"},
+		},
+		{
+			name:   "same timestamp keeps arrival order",
+			window: 200 * time.Millisecond,
+			events: []*Event{
+				orderedEvent("a", "u1", time.Second),
+				orderedEvent("b", "u1", time.Second),
+				orderedEvent("c", "u1", 0),
+			},
+			want: []string{"c", "a", "b"},
+		},
+		{
+			name:   "external id takes precedence over user",
+			window: 200 * time.Millisecond,
+			events: func() []*Event {
+				late := orderedEvent("late", "other", 2*time.Second)
+				early := orderedEvent("early", "", time.Second)
+				id := "other"
+				early.ExternalID = &id
+				return []*Event{late, early}
+			}(),
+			want: []string{"early", "late"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h handledOrder
+			d := NewOrderedDispatcher(h.handle, tt.window, nil)
+			dispatchStaggered(t, d, tt.events, 10*time.Millisecond)
+			assertStrings(t, "handled", h.got(), tt.want)
+			if n := d.Pending(); n != 0 {
+				t.Errorf("Pending() = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestOrderedDispatcherLateEvent(t *testing.T) {
+	var h handledOrder
+	started := make(chan struct{})
+	release := make(chan struct{})
+	logger := &recordingLogger{}
+	d := NewOrderedDispatcher(func(ctx context.Context, e *Event) error {
+		if e.EventID == "renewing" {
+			close(started)
+			<-release
+		}
+		return h.handle(ctx, e)
+	}, 0, logger)
+
+	// 较早的事件在同一用户的前一个事件处理过程中到达
+	errs := make(chan error, 2)
+	go func() { errs <- d.Dispatch(context.Background(), orderedEvent("renewing", "u1", 2*time.Second)) }()
+	<-started
+	go func() { errs <- d.Dispatch(context.Background(), orderedEvent("starting_trial", "u1", time.Second)) }()
+	for d.Pending() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Fatalf("Dispatch() = %v", err)
+		}
+	}
+
+	assertStrings(t, "handled", h.got(), []string{"renewing", "starting_trial"})
+	assertStrings(t, "logs", logger.messages(), []string{"funnelfox_event_out_of_order"})
+}
+
+func TestOrderedDispatcherConcurrency(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		running = map[string]int{}
+		maxSame int
+		maxAll  int
+		total   int
+	)
+	started := make(chan struct{}, 8)
+	release := make(chan struct{})
+	handle := func(ctx context.Context, e *Event) error {
+		user := e.User.ExternalID
+		mu.Lock()
+		running[user]++
+		total++
+		maxSame = max(maxSame, running[user])
+		maxAll = max(maxAll, total)
+		mu.Unlock()
+		started <- struct{}{}
+		<-release
+		mu.Lock()
+		running[user]--
+		total--
+		mu.Unlock()
+		return nil
+	}
+	d := NewOrderedDispatcher(handle, 0, nil)
+
+	var wg sync.WaitGroup
+	for _, e := range []*Event{
+		orderedEvent("a1", "a", 0),
+		orderedEvent("a2", "a", time.Second),
+		orderedEvent("b1", "b", 0),
+		orderedEvent("b2", "b", time.Second),
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.Dispatch(context.Background(), e)
+		}()
+	}
+	// 两个用户的首个事件同时处理中
+	for range 2 {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("users are not processed in parallel")
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if maxSame != 1 {
+		t.Errorf("max concurrent per user = %d, want 1", maxSame)
+	}
+	if maxAll < 2 {
+		t.Errorf("max concurrent overall = %d, want >= 2", maxAll)
+	}
+}
+
+func TestOrderedDispatcherDispatchResult(t *testing.T) {
+	errHandle := errors.New("handler failed")
+	tests := []struct {
+		name        string
+		user        string
+		window      time.Duration
+		cancel      bool
+		wantErr     error
+		wantHandled []string
+	}{
+		{name: "handler error", user: "u1", wantErr: errHandle, wantHandled: []string{"e1"}},
+		{name: "no external id skips window", user: "", window: time.Hour, wantErr: errHandle, wantHandled: []string{"e1"}},
+		{name: "cancelled before start", user: "u1", window: time.Hour, cancel: true, wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h handledOrder
+			d := NewOrderedDispatcher(func(ctx context.Context, e *Event) error {
+				h.handle(ctx, e)
+				return errHandle
+			}, tt.window, nil)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(20*time.Millisecond, cancel)
+			}
+			err := d.Dispatch(ctx, orderedEvent("e1", tt.user, 0))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Dispatch() = %v, want %v", err, tt.wantErr)
+			}
+			// 被取消的事件在 Dispatch 返回前已移出队列
+			if n := d.Pending(); n != 0 {
+				t.Errorf("Pending() = %d after Dispatch returned", n)
+			}
+			assertStrings(t, "handled", h.got(), tt.wantHandled)
+		})
+	}
+}
+
+func TestOrderedDispatcherPendingExcludesCancelled(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var h handledOrder
+	d := NewOrderedDispatcher(func(ctx context.Context, e *Event) error {
+		if e.EventID == "e1" {
+			close(started)
+			<-release
+		}
+		return h.handle(ctx, e)
+	}, 0, nil)
+
+	first := make(chan error, 1)
+	go func() { first <- d.Dispatch(context.Background(), orderedEvent("e1", "u1", 0)) }()
+	<-started
+
+	// e2 排在处理中的 e1 之后，取消后立即从 Pending 中移除
+	ctx, cancel := context.WithCancel(context.Background())
+	second := make(chan error, 1)
+	go func() { second <- d.Dispatch(ctx, orderedEvent("e2", "u1", time.Second)) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for d.Pending() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("e2 not queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-second; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Dispatch(e2) = %v, want context.Canceled", err)
+	}
+	if n := d.Pending(); n != 0 {
+		t.Errorf("Pending() = %d after cancel, want 0", n)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+	assertStrings(t, "handled", h.got(), []string{"e1"})
+}
@@ -0,0 +1,238 @@
+package funnelfox
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxWebhookBodySize webhook 请求体的最大字节数，超过时返回 413
+const maxWebhookBodySize = 1 << 20
+
+// ErrWebhookUnauthorized webhook 请求校验失败
+var ErrWebhookUnauthorized = errors.New("funnelfox: unauthorized webhook request")
+
+// WebhookVerifier 在处理事件前校验 webhook 请求，orgID 为解析出的组织ID，返回错误时请求被拒绝
+type WebhookVerifier func(r *http.Request, orgID string, body []byte) error
+
+// WebhookHeaderSecret 校验请求头 header 的值与 secret 一致
+func WebhookHeaderSecret(header, secret string) WebhookVerifier {
+	return WebhookHeaderSecrets(header, func(string) string { return secret })
+}
+
+// WebhookHeaderSecrets 校验请求头 header 的值与组织对应的 secret 一致，secret 为空时拒绝请求
+func WebhookHeaderSecrets(header string, secret func(orgID string) string) WebhookVerifier {
+	return func(r *http.Request, orgID string, body []byte) error {
+		want := secret(orgID)
+		if want == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(header)), []byte(want)) != 1 {
+			return ErrWebhookUnauthorized
+		}
+		return nil
+	}
+}
+
+// OrgResolver 从 webhook 请求中解析组织ID
+type OrgResolver func(r *http.Request, body []byte) (orgID string, ok bool)
+
+// OrgFromPath 使用 URL 路径的最后一段作为组织ID，如 /webhooks/funnelfox/{orgID}
+func OrgFromPath() OrgResolver {
+	return func(r *http.Request, body []byte) (string, bool) {
+		path := strings.TrimRight(r.URL.Path, "/")
+		i := strings.LastIndex(path, "/")
+		orgID := path[i+1:]
+		return orgID, orgID != ""
+	}
+}
+
+// OrgFromPayloadField 使用 webhook 请求体中顶层字段 field 的值作为组织ID
+func OrgFromPayloadField(field string) OrgResolver {
+	return func(r *http.Request, body []byte) (string, bool) {
+		var payload map[string]json.RawMessage
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return "", false
+		}
+		var orgID string
+		if err := json.Unmarshal(payload[field], &orgID); err != nil {
+			return "", false
+		}
+		return orgID, orgID != ""
+	}
+}
+
+// ClientPool 按组织ID管理多个 Client，共享 HTTP 客户端、限流器和指标记录器
+type ClientPool struct {
+	mu          sync.RWMutex
+	clients     map[string]*Client
+	httpClient  *http.Client
+	logger      Logger
+	limiter     Limiter
+	metrics     MetricsRecorder
+	orgResolver OrgResolver
+	verifier    WebhookVerifier
+}
+
+// NewClientPool 创建 ClientPool
+// httpClient: 所有组织共享的 HTTP 客户端，可以为 nil（使用默认客户端）
+// logger: 日志记录器，可以为 nil（使用 NopLogger）
+func NewClientPool(httpClient *http.Client, logger Logger) *ClientPool {
+	if httpClient == nil {
+		httpClient = defaultFunnelFoxHTTPClient
+	}
+	if logger == nil {
+		logger = &NopLogger{}
+	}
+	return &ClientPool{
+		clients:     make(map[string]*Client),
+		httpClient:  httpClient,
+		logger:      logger,
+		metrics:     &NopMetricsRecorder{},
+		orgResolver: OrgFromPath(),
+	}
+}
+
+// SetLimiter 设置所有组织共享的限流器
+func (p *ClientPool) SetLimiter(l Limiter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limiter = l
+	for _, c := range p.clients {
+		c.SetLimiter(l)
+	}
+}
+
+// SetMetricsRecorder 设置所有组织共享的指标记录器
+func (p *ClientPool) SetMetricsRecorder(m MetricsRecorder) {
+	if m == nil {
+		m = &NopMetricsRecorder{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metrics = m
+	for _, c := range p.clients {
+		c.SetMetricsRecorder(m)
+	}
+}
+
+// SetOrgResolver 设置 webhook 路由使用的组织ID解析方式，默认 OrgFromPath
+func (p *ClientPool) SetOrgResolver(resolver OrgResolver) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.orgResolver = resolver
+}
+
+// SetWebhookVerifier 设置 webhook 请求校验，默认不校验
+func (p *ClientPool) SetWebhookVerifier(v WebhookVerifier) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.verifier = v
+}
+
+// Add 添加（或替换）组织的客户端并返回
+func (p *ClientPool) Add(orgID, secretKey string) *Client {
+	c := NewClientWithHTTPClient(orgID, secretKey, p.httpClient, p.logger)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c.SetLimiter(p.limiter)
+	c.SetMetricsRecorder(p.metrics)
+	p.clients[orgID] = c
+	return c
+}
+
+// Get 获取组织的客户端
+func (p *ClientPool) Get(orgID string) (*Client, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	c, ok := p.clients[orgID]
+	return c, ok
+}
+
+// Remove 移除组织的客户端
+func (p *ClientPool) Remove(orgID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.clients, orgID)
+}
+
+// OrgIDs 返回已注册的组织ID（已排序）
+func (p *ClientPool) OrgIDs() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	ids := make([]string, 0, len(p.clients))
+	for id := range p.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// WebhookHandler 返回 http.Handler，按组织ID将 webhook 路由到对应客户端并调用 handle
+// 请求体超过 1MB 时返回 413；设置了 SetWebhookVerifier 时，校验失败返回 401 且不解析事件
+func (p *ClientPool) WebhookHandler(handle func(ctx context.Context, client *Client, event *Event) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				p.logger.Error("funnelfox_webhook_body_too_large",
+					String("path", r.URL.Path),
+					Number("limit", int(tooLarge.Limit)))
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		p.mu.RLock()
+		resolver, verifier := p.orgResolver, p.verifier
+		p.mu.RUnlock()
+		orgID, ok := resolver(r, body)
+		if !ok {
+			p.logger.Error("funnelfox_webhook_org_unresolved",
+				String("path", r.URL.Path))
+			http.Error(w, "unknown organization", http.StatusNotFound)
+			return
+		}
+		client, ok := p.Get(orgID)
+		if !ok {
+			p.logger.Error("funnelfox_webhook_org_unknown",
+				String("org_id", orgID))
+			http.Error(w, "unknown organization", http.StatusNotFound)
+			return
+		}
+		if verifier != nil {
+			if err := verifier(r, orgID, body); err != nil {
+				p.logger.Error("funnelfox_webhook_unauthorized",
+					String("org_id", orgID),
+					ErrorField(err))
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		event, err := client.WithContext(r.Context()).HandleEvent(body, func(ctx context.Context, event *Event) error {
+			if handle == nil {
+				return nil
+			}
+			return handle(ctx, client, event)
+		})
+		if err != nil {
+			p.logger.Error("funnelfox_webhook_error",
+				String("org_id", orgID),
+				ErrorField(err))
+			if event == nil {
+				http.Error(w, "invalid event", http.StatusBadRequest)
+			} else {
+				http.Error(w, "failed to handle event", http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,142 @@
+package funnelfox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOrgResolvers(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver OrgResolver
+		path     string
+		body     string
+		wantOrg  string
+		wantOK   bool
+	}{
+		{"path", OrgFromPath(), "/webhooks/funnelfox/org1", "", "org1", true},
+		{"path trailing slash", OrgFromPath(), "/webhooks/funnelfox/org1/", "", "org1", true},
+		{"path root", OrgFromPath(), "/", "", "", false},
+		{"payload", OrgFromPayloadField("org_id"), "/", `{"org_id":"org2"}`, "org2", true},
+		{"payload missing", OrgFromPayloadField("org_id"), "/", `{"event_id":"e1"}`, "", false},
+		{"payload not string", OrgFromPayloadField("org_id"), "/", `{"org_id":1}`, "", false},
+		{"payload invalid", OrgFromPayloadField("org_id"), "/", `{`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			org, ok := tt.resolver(r, []byte(tt.body))
+			if org != tt.wantOrg || ok != tt.wantOK {
+				t.Errorf("got (%q, %v), want (%q, %v)", org, ok, tt.wantOrg, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClientPool(t *testing.T) {
+	p := NewClientPool(nil, nil)
+	m := &fakeMetrics{}
+	p.SetMetricsRecorder(m)
+	a := p.Add("b-org", "kb")
+	p.Add("a-org", "ka")
+
+	if got, ok := p.Get("b-org"); !ok || got != a {
+		t.Errorf("Get(b-org) = %v, %v", got, ok)
+	}
+	if a.metrics != m {
+		t.Error("added client does not share metrics recorder")
+	}
+	assertStrings(t, "org ids", p.OrgIDs(), []string{"a-org", "b-org"})
+
+	p.Remove("b-org")
+	if _, ok := p.Get("b-org"); ok {
+		t.Error("removed client still present")
+	}
+	assertStrings(t, "org ids", p.OrgIDs(), []string{"a-org"})
+}
+
+func TestWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		handleErr  error
+		wantStatus int
+		wantOrg    string
+	}{
+		{"routed", "/webhooks/org1", testEvent, nil, http.StatusOK, "org1"},
+		{"unknown org", "/webhooks/org3", testEvent, nil, http.StatusNotFound, ""},
+		{"invalid event", "/webhooks/org1", `{`, nil, http.StatusBadRequest, ""},
+		{"handler error", "/webhooks/org2", testEvent, errors.New("boom"), http.StatusInternalServerError, "org2"},
+		{"body too large", "/webhooks/org1", `{"pad":"` + strings.Repeat("x", maxWebhookBodySize) + `"}`, nil, http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewClientPool(nil, nil)
+			p.Add("org1", "k1")
+			p.Add("org2", "k2")
+
+			var gotOrg string
+			h := p.WebhookHandler(func(ctx context.Context, client *Client, event *Event) error {
+				gotOrg = client.orgID
+				return tt.handleErr
+			})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if gotOrg != tt.wantOrg {
+				t.Errorf("handled by %q, want %q", gotOrg, tt.wantOrg)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerVerifier(t *testing.T) {
+	secrets := map[string]string{"org1": "s1", "org2": "s2"}
+	tests := []struct {
+		name       string
+		verifier   WebhookVerifier
+		path       string
+		secret     string
+		wantStatus int
+		wantOrg    string
+	}{
+		{"per org secret", WebhookHeaderSecrets("X-Secret", func(org string) string { return secrets[org] }), "/webhooks/org2", "s2", http.StatusOK, "org2"},
+		{"secret of another org", WebhookHeaderSecrets("X-Secret", func(org string) string { return secrets[org] }), "/webhooks/org2", "s1", http.StatusUnauthorized, ""},
+		{"missing secret", WebhookHeaderSecret("X-Secret", "shared"), "/webhooks/org1", "", http.StatusUnauthorized, ""},
+		{"shared secret", WebhookHeaderSecret("X-Secret", "shared"), "/webhooks/org1", "shared", http.StatusOK, "org1"},
+		{"empty configured secret", WebhookHeaderSecret("X-Secret", ""), "/webhooks/org1", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewClientPool(nil, nil)
+			p.Add("org1", "k1")
+			p.Add("org2", "k2")
+			p.SetWebhookVerifier(tt.verifier)
+
+			var gotOrg string
+			h := p.WebhookHandler(func(ctx context.Context, client *Client, event *Event) error {
+				gotOrg = client.orgID
+				return nil
+			})
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(testEvent))
+			if tt.secret != "" {
+				r.Header.Set("X-Secret", tt.secret)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if gotOrg != tt.wantOrg {
+				t.Errorf("handled by %q, want %q", gotOrg, tt.wantOrg)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package funnelfox
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// 周期单位简写，便于构建价格点
+const (
+	Minutes = PeriodDurationUnitMinutes
+	Days    = PeriodDurationUnitDays
+	Weeks   = PeriodDurationUnitWeeks
+	Months  = PeriodDurationUnitMonths
+	Years   = PeriodDurationUnitYears
+)
+
+var moneyPattern = regexp.MustCompile(`^\d+(\.\d{1,4})?$`)
+
+// Money 十进制字符串表示的金额，如 "9.99"，避免直接书写 float64
+type Money string
+
+// Float64 解析金额
+func (m Money) Float64() (float64, error) {
+	if !moneyPattern.MatchString(string(m)) {
+		return 0, NewError("invalid money amount " + strconv.Quote(string(m)))
+	}
+	return strconv.ParseFloat(string(m), 64)
+}
+
+// PricePointBuilder 价格点创建请求构建器
+//
+//	req, err := NewPricePoint("pro_monthly").
+//		Currency("USD").
+//		FreeTrial(7, Days).
+//		Recurring("9.99", 1, Months).
+//		Features("pro").
+//		Build()
+type PricePointBuilder struct {
+	req  PricePointCreateRequest
+	errs fieldErrors
+}
+
+// NewPricePoint 创建价格点构建器
+func NewPricePoint(ident string) *PricePointBuilder {
+	return &PricePointBuilder{
+		req: PricePointCreateRequest{
+			Ident:     ident,
+			IntroType: IntroTypeNoIntro,
+		},
+	}
+}
+
+// Currency 设置货币代码
+func (b *PricePointBuilder) Currency(code string) *PricePointBuilder {
+	b.req.CurrencyCode = code
+	return b
+}
+
+// Descriptor 设置账单描述
+func (b *PricePointBuilder) Descriptor(descriptor string) *PricePointBuilder {
+	b.req.Descriptor = descriptor
+	return b
+}
+
+// Features 追加关联的 feature
+func (b *PricePointBuilder) Features(idents ...string) *PricePointBuilder {
+	b.req.Features = append(b.req.Features, idents...)
+	return b
+}
+
+// FreeTrial 设置免费试用
+func (b *PricePointBuilder) FreeTrial(period int, unit PeriodDurationUnit) *PricePointBuilder {
+	b.req.IntroType = IntroTypeFreeTrial
+	b.req.IntroFreeTrialPeriod = period
+	b.req.IntroFreeTrialPeriodDuration = unit
+	return b
+}
+
+// PaidTrial 设置付费试用
+func (b *PricePointBuilder) PaidTrial(price Money, period int, unit PeriodDurationUnit) *PricePointBuilder {
+	b.req.IntroType = IntroTypePaidTrial
+	b.req.IntroPaidTrialPrice = b.money("intro_paid_trial_price", price)
+	b.req.IntroPaidTrialPeriod = period
+	b.req.IntroPaidTrialPeriodDuration = unit
+	return b
+}
+
+// Recurring 设置续费价格和周期
+func (b *PricePointBuilder) Recurring(price Money, period int, unit PeriodDurationUnit) *PricePointBuilder {
+	b.req.NextPrice = b.money("next_price", price)
+	b.req.NextPeriod = period
+	b.req.NextPeriodDuration = unit
+	return b
+}
+
+// Lifetime 设置终身价格（一次性付费）
+func (b *PricePointBuilder) Lifetime(price Money) *PricePointBuilder {
+	b.req.LifetimePrice = b.money("lifetime_price", price)
+	return b
+}
+
+// Build 生成价格点创建请求，字段不合法时返回 *ValidationError
+func (b *PricePointBuilder) Build() (PricePointCreateRequest, error) {
+	fe := append(fieldErrors(nil), b.errs...)
+	if err := b.req.Validate(); err != nil {
+		// 金额解析失败的字段不再重复报告
+		invalid := make(map[string]bool, len(b.errs))
+		for _, e := range b.errs {
+			invalid[e.Field] = true
+		}
+		for _, e := range err.(*ValidationError).Fields {
+			if !invalid[e.Field] {
+				fe = append(fe, e)
+			}
+		}
+	}
+	if err := fe.err(); err != nil {
+		return PricePointCreateRequest{}, err
+	}
+	return b.req, nil
+}
+
+func (b *PricePointBuilder) money(field string, m Money) float64 {
+	v, err := m.Float64()
+	if err != nil {
+		b.errs.add(field, "invalid money amount %q", m)
+	}
+	return v
+}
@@ -0,0 +1,129 @@
+package funnelfox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMoneyFloat64(t *testing.T) {
+	tests := []struct {
+		in      Money
+		want    float64
+		wantErr bool
+	}{
+		{"9.99", 9.99, false},
+		{"10", 10, false},
+		{"0.0001", 0.0001, false},
+		{"0.00001", 0, true},
+		{"-1", 0, true},
+		{"1e3", 0, true},
+		{"", 0, true},
+		{"9,99", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.in), func(t *testing.T) {
+			got, err := tt.in.Float64()
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("Float64() = %v, %v; want %v, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPricePointBuilder(t *testing.T) {
+	tests := []struct {
+		name       string
+		builder    *PricePointBuilder
+		want       PricePointCreateRequest
+		wantFields []string
+	}{
+		{
+			name: "free trial",
+			builder: NewPricePoint("pro_monthly").
+				Currency("USD").
+				FreeTrial(7, Days).
+				Recurring("9.99", 1, Months).
+				Features("pro", "ads_free"),
+			want: PricePointCreateRequest{
+				Ident:                        "pro_monthly",
+				CurrencyCode:                 "USD",
+				IntroType:                    IntroTypeFreeTrial,
+				IntroFreeTrialPeriod:         7,
+				IntroFreeTrialPeriodDuration: PeriodDurationUnitDays,
+				NextPrice:                    9.99,
+				NextPeriod:                   1,
+				NextPeriodDuration:           PeriodDurationUnitMonths,
+				Features:                     []string{"pro", "ads_free"},
+			},
+		},
+		{
+			name: "paid trial",
+			builder: NewPricePoint("pro_weekly").
+				Currency("EUR").
+				PaidTrial("0.99", 1, Weeks).
+				Recurring("4.99", 1, Weeks).
+				Descriptor("PRO"),
+			want: PricePointCreateRequest{
+				Ident:                        "pro_weekly",
+				CurrencyCode:                 "EUR",
+				IntroType:                    IntroTypePaidTrial,
+				IntroPaidTrialPrice:          0.99,
+				IntroPaidTrialPeriod:         1,
+				IntroPaidTrialPeriodDuration: PeriodDurationUnitWeeks,
+				NextPrice:                    4.99,
+				NextPeriod:                   1,
+				NextPeriodDuration:           PeriodDurationUnitWeeks,
+				Descriptor:                   "PRO",
+			},
+		},
+		{
+			name:    "lifetime",
+			builder: NewPricePoint("lifetime").Currency("USD").Lifetime("99"),
+			want: PricePointCreateRequest{
+				Ident:         "lifetime",
+				IntroType:     IntroTypeNoIntro,
+				CurrencyCode:  "USD",
+				LifetimePrice: 99,
+			},
+		},
+		{
+			name:       "invalid money reported once",
+			builder:    NewPricePoint("bad").Currency("USD").Recurring("9.999.9", 1, Months),
+			wantFields: []string{"next_price"},
+		},
+		{
+			name:       "free trial and paid trial",
+			builder:    NewPricePoint("both").Currency("USD").FreeTrial(3, Days).PaidTrial("1", 1, Weeks).Recurring("5", 1, Months),
+			wantFields: []string{"intro_free_trial_period"},
+		},
+		{
+			name:       "missing currency and period",
+			builder:    NewPricePoint("x"),
+			wantFields: []string{"currency_code", "next_price", "next_period", "next_period_duration"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.builder.Build()
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("got %+v\nwant %+v", got, tt.want)
+				}
+				return
+			}
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("err = %v, want *ValidationError", err)
+			}
+			fields := make([]string, 0, len(ve.Fields))
+			for _, f := range ve.Fields {
+				fields = append(fields, f.Field)
+			}
+			assertStrings(t, "fields", fields, tt.wantFields)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Package prommetrics 提供基于 Prometheus 的 funnelfox.MetricsRecorder 实现
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/byte-power/funnelfox"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "funnelfox"
+
+// Recorder Prometheus 指标记录器
+type Recorder struct {
+	requests  *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	apiErrors *prometheus.CounterVec
+	events    *prometheus.CounterVec
+}
+
+var _ funnelfox.MetricsRecorder = (*Recorder)(nil)
+
+// NewRecorder 创建 Prometheus 指标记录器并注册到 reg
+// reg 为 nil 时使用 prometheus.DefaultRegisterer
+func NewRecorder(reg prometheus.Registerer) (*Recorder, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	r := &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "FunnelFox API requests by endpoint and result.",
+		}, []string{"endpoint", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "FunnelFox API request latency by endpoint.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+		apiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_errors_total",
+			Help:      "FunnelFox API errors by endpoint and error type.",
+		}, []string{"endpoint", "type"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_events_total",
+			Help:      "FunnelFox webhook events by event type and subtype.",
+		}, []string{"event_type", "subtype"}),
+	}
+	for _, c := range []prometheus.Collector{r.requests, r.latency, r.apiErrors, r.events} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) ObserveRequest(endpoint string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	r.requests.WithLabelValues(endpoint, result).Inc()
+	r.latency.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
+
+func (r *Recorder) IncAPIError(endpoint, errorType string) {
+	r.apiErrors.WithLabelValues(endpoint, errorType).Inc()
+}
+
+func (r *Recorder) IncEvent(eventType funnelfox.EventType, subtype funnelfox.EventSubtype) {
+	r.events.WithLabelValues(string(eventType), string(subtype)).Inc()
+}
@@ -0,0 +1,49 @@
+package prommetrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/byte-power/funnelfox"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecorder(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	r, err := NewRecorder(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.ObserveRequest("/subscription/pause", 10*time.Millisecond, nil)
+	r.ObserveRequest("/subscription/pause", 20*time.Millisecond, errors.New("boom"))
+	r.ObserveRequest("/subscription/pause", 30*time.Millisecond, nil)
+	r.IncAPIError("/subscription/pause", "invalid_request")
+	r.IncEvent(funnelfox.EventTypeOrder, funnelfox.EventSubtypeOrderSettled)
+
+	tests := []struct {
+		name   string
+		metric prometheus.Collector
+		want   float64
+	}{
+		{"requests success", r.requests.WithLabelValues("/subscription/pause", "success"), 2},
+		{"requests error", r.requests.WithLabelValues("/subscription/pause", "error"), 1},
+		{"api errors", r.apiErrors.WithLabelValues("/subscription/pause", "invalid_request"), 1},
+		{"events", r.events.WithLabelValues("order", "settled"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testutil.ToFloat64(tt.metric); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if n := testutil.CollectAndCount(r.latency); n != 1 {
+		t.Errorf("latency series = %d, want 1", n)
+	}
+
+	if _, err := NewRecorder(reg); err == nil {
+		t.Error("registering twice should fail")
+	}
+}
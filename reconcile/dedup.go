@@ -0,0 +1,75 @@
+// Package reconcile 使用交易报告补齐 webhook 缺失的订单事件，并核对支付记录与交易报告
+package reconcile
+
+import (
+	"context"
+	"sync"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// DedupStore 记录已处理的订单事件
+//
+// 以 (订单ID, 事件子类型) 为键，订单先失败后重试成功时 settled 事件仍可被补齐
+type DedupStore interface {
+	// IsProcessed 判断订单的该类事件是否已处理
+	IsProcessed(ctx context.Context, orderID string, subtype funnelfox.EventSubtype) (bool, error)
+	// MarkProcessed 标记订单的该类事件已处理
+	MarkProcessed(ctx context.Context, orderID string, subtype funnelfox.EventSubtype) error
+}
+
+// MemoryDedupStore 内存去重存储
+type MemoryDedupStore struct {
+	mu        sync.Mutex
+	processed map[string]bool
+}
+
+var _ DedupStore = (*MemoryDedupStore)(nil)
+
+// NewMemoryDedupStore 创建内存去重存储
+func NewMemoryDedupStore() *MemoryDedupStore {
+	return &MemoryDedupStore{processed: make(map[string]bool)}
+}
+
+func dedupKey(orderID string, subtype funnelfox.EventSubtype) string {
+	return orderID + "\x00" + string(subtype)
+}
+
+func (s *MemoryDedupStore) IsProcessed(ctx context.Context, orderID string, subtype funnelfox.EventSubtype) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.processed[dedupKey(orderID, subtype)], nil
+}
+
+func (s *MemoryDedupStore) MarkProcessed(ctx context.Context, orderID string, subtype funnelfox.EventSubtype) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.processed[dedupKey(orderID, subtype)] = true
+	return nil
+}
+
+// TrackOrders 包装事件处理函数，订单 settled/declined 事件处理成功后写入 store
+// 在 webhook 处理链路中使用，使 Reconciler 能识别已经通过 webhook 处理过的订单
+func TrackOrders(store DedupStore, handle func(ctx context.Context, event *funnelfox.Event) error) func(ctx context.Context, event *funnelfox.Event) error {
+	return func(ctx context.Context, event *funnelfox.Event) error {
+		if err := handle(ctx, event); err != nil {
+			return err
+		}
+		if orderID, subtype, ok := orderOutcome(event); ok {
+			return store.MarkProcessed(ctx, orderID, subtype)
+		}
+		return nil
+	}
+}
+
+// orderOutcome 返回订单结果事件的订单ID和子类型
+func orderOutcome(event *funnelfox.Event) (string, funnelfox.EventSubtype, bool) {
+	if event.EventType != funnelfox.EventTypeOrder || event.Order == nil || event.Order.OrderID == "" {
+		return "", "", false
+	}
+	switch event.Subtype {
+	case funnelfox.EventSubtypeOrderSettled, funnelfox.EventSubtypeOrderDeclined:
+		return event.Order.OrderID, event.Subtype, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,309 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// DiscrepancyKind 差异类型
+type DiscrepancyKind string
+
+const (
+	DiscrepancyAmountMismatch         DiscrepancyKind = "amount_mismatch"           // 金额不一致
+	DiscrepancyCurrencyMismatch       DiscrepancyKind = "currency_mismatch"         // 货币不一致
+	DiscrepancyRefundMissingInReport  DiscrepancyKind = "refund_missing_in_report"  // 支付记录有退款，交易报告没有
+	DiscrepancyRefundMissingInHistory DiscrepancyKind = "refund_missing_in_history" // 交易报告有退款，支付记录没有
+	DiscrepancyMissingInHistory       DiscrepancyKind = "missing_in_history"        // 成功交易不在用户支付记录中
+	DiscrepancyMissingInReport        DiscrepancyKind = "missing_in_report"         // 时间窗口内的支付不在交易报告中
+	DiscrepancyNotSettledInReport     DiscrepancyKind = "not_settled_in_report"     // 交易报告中该订单没有成功扣款（如只有失败或处理中的交易）
+)
+
+// Discrepancy 一条差异
+type Discrepancy struct {
+	Kind            DiscrepancyKind `json:"kind"`
+	ExternalID      string          `json:"external_id"`
+	OrderID         string          `json:"order_id"`
+	SubsID          string          `json:"subs_id,omitempty"`
+	OneoffID        string          `json:"oneoff_id,omitempty"`
+	PaymentAmount   string          `json:"payment_amount,omitempty"`
+	ReportAmount    string          `json:"report_amount,omitempty"`
+	PaymentCurrency string          `json:"payment_currency,omitempty"`
+	ReportCurrency  string          `json:"report_currency,omitempty"`
+	Detail          string          `json:"detail,omitempty"`
+}
+
+// PaymentsReport 支付记录与交易报告的核对结果
+type PaymentsReport struct {
+	From          time.Time     `json:"from"`
+	To            time.Time     `json:"to"`
+	Users         int           `json:"users"`
+	Payments      int           `json:"payments"`
+	Transactions  int           `json:"transactions"`
+	Matched       int           `json:"matched"` // 与成功扣款核对一致的订单数量
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+// WriteJSON 以缩进 JSON 输出报告
+func (r *PaymentsReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Count 返回指定类型的差异数量
+func (r *PaymentsReport) Count(kind DiscrepancyKind) int {
+	n := 0
+	for _, d := range r.Discrepancies {
+		if d.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// ReconcilePayments 拉取 [from, to) 内的交易报告和 externalIDs 中每个用户的支付记录，按 OrderID 核对
+//
+// 窗口内有退款但窗口内没有退款交易的订单，会按 OrderID 单独查询窗口之后的退款交易
+func ReconcilePayments(ctx context.Context, client *funnelfox.Client, externalIDs []string, from, to time.Time) (*PaymentsReport, error) {
+	client = client.WithContext(ctx)
+	var transactions []funnelfox.Transaction
+	refundChecked := make(map[string]bool) // 已有退款交易或已补查的订单
+	for t, err := range client.Transactions(funnelfox.TransactionReportRequest{LastTransactionDate: from}) {
+		if err != nil {
+			return nil, err
+		}
+		if t.TrxCreatedAt != nil && !t.TrxCreatedAt.Before(to) {
+			break
+		}
+		transactions = append(transactions, t)
+		if t.IsRefund() {
+			refundChecked[t.OrderID] = true
+		}
+	}
+
+	history := make(map[string][]funnelfox.Payment, len(externalIDs))
+	for _, externalID := range externalIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res, err := client.GetPaymentsHistory(funnelfox.PaymentsHistoryRequest{ExternalID: externalID})
+		if err != nil {
+			return nil, err
+		}
+		history[externalID] = res.Payments
+	}
+
+	// 退款可能发生在窗口之后，按订单补查退款交易
+	for _, externalID := range externalIDs {
+		for _, p := range history[externalID] {
+			if refundChecked[p.OrderID] || !isPositiveAmount(p.Refunded) || !paymentInWindow(p, from, to) {
+				continue
+			}
+			refundChecked[p.OrderID] = true
+			orderID := p.OrderID
+			for t, err := range client.Transactions(funnelfox.TransactionReportRequest{LastTransactionDate: from, OrderID: &orderID}) {
+				if err != nil {
+					return nil, err
+				}
+				if t.IsRefund() && (t.TrxCreatedAt == nil || !t.TrxCreatedAt.Before(to)) {
+					transactions = append(transactions, t)
+				}
+			}
+		}
+	}
+	return ComparePayments(history, transactions, from, to), nil
+}
+
+// ComparePayments 核对支付记录（按用户外部ID分组）与交易
+//
+// 只核对创建时间在 [from, to) 内的支付记录（没有创建时间的按是否有对应交易判断），
+// 只有 [from, to) 内的成功交易会被报告为 missing_in_history；窗口之后的交易仅用于确认退款。
+// 交易报告不包含用户信息，交易通过 OrderID，以及用户支付记录中出现过的订阅ID或一次性购买ID归属到用户
+func ComparePayments(history map[string][]funnelfox.Payment, transactions []funnelfox.Transaction, from, to time.Time) *PaymentsReport {
+	report := &PaymentsReport{
+		From:          from,
+		To:            to,
+		Users:         len(history),
+		Discrepancies: []Discrepancy{},
+	}
+
+	byOrder := make(map[string][]funnelfox.Transaction)
+	var charges []funnelfox.Transaction // 窗口内的成功扣款
+	for _, t := range transactions {
+		if !transactionInWindow(t, from, to) {
+			if t.OrderID != "" && t.IsRefund() {
+				byOrder[t.OrderID] = append(byOrder[t.OrderID], t)
+			}
+			continue
+		}
+		report.Transactions++
+		if t.OrderID != "" {
+			byOrder[t.OrderID] = append(byOrder[t.OrderID], t)
+		}
+		if t.IsSettled() && !t.IsRefund() {
+			charges = append(charges, t)
+		}
+	}
+
+	externalIDs := make([]string, 0, len(history))
+	for id := range history {
+		externalIDs = append(externalIDs, id)
+	}
+	sort.Strings(externalIDs)
+
+	for _, externalID := range externalIDs {
+		orders := make(map[string]bool)
+		subs := make(map[string]bool)
+		oneoffs := make(map[string]bool)
+		for _, p := range history[externalID] {
+			orders[p.OrderID] = true
+			if p.SubsID != "" {
+				subs[p.SubsID] = true
+			}
+			if p.OneoffID != nil && *p.OneoffID != "" {
+				oneoffs[*p.OneoffID] = true
+			}
+			if (p.CreatedAt == nil && len(byOrder[p.OrderID]) == 0) || !paymentInWindow(p, from, to) {
+				continue
+			}
+			report.Payments++
+			ds := comparePayment(externalID, p, byOrder[p.OrderID])
+			// 没有交易或没有成功扣款时 comparePayment 总会返回差异，不计入 Matched
+			if len(ds) == 0 {
+				report.Matched++
+			}
+			report.Discrepancies = append(report.Discrepancies, ds...)
+		}
+
+		// 属于该用户但不在支付记录中的成功交易
+		missing := make(map[string]bool)
+		for _, t := range charges {
+			if orders[t.OrderID] || missing[t.OrderID] {
+				continue
+			}
+			if !(t.MetaSubsID != nil && subs[*t.MetaSubsID]) && !(t.MetaOneoffID != nil && oneoffs[*t.MetaOneoffID]) {
+				continue
+			}
+			missing[t.OrderID] = true
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:           DiscrepancyMissingInHistory,
+				ExternalID:     externalID,
+				OrderID:        t.OrderID,
+				SubsID:         deref(t.MetaSubsID),
+				OneoffID:       deref(t.MetaOneoffID),
+				ReportAmount:   t.Amount,
+				ReportCurrency: t.CurrencyCode,
+			})
+		}
+	}
+	return report
+}
+
+// paymentInWindow 支付创建时间是否在 [from, to) 内，没有创建时间时返回 true
+func paymentInWindow(p funnelfox.Payment, from, to time.Time) bool {
+	return p.CreatedAt == nil || !p.CreatedAt.Before(from) && p.CreatedAt.Before(to)
+}
+
+// transactionInWindow 交易创建时间是否在 [from, to) 内，没有创建时间时返回 true
+func transactionInWindow(t funnelfox.Transaction, from, to time.Time) bool {
+	return t.TrxCreatedAt == nil || !t.TrxCreatedAt.Before(from) && t.TrxCreatedAt.Before(to)
+}
+
+// comparePayment 核对单条支付记录与同一订单的交易
+func comparePayment(externalID string, p funnelfox.Payment, transactions []funnelfox.Transaction) []Discrepancy {
+	base := Discrepancy{
+		ExternalID:      externalID,
+		OrderID:         p.OrderID,
+		SubsID:          p.SubsID,
+		OneoffID:        deref(p.OneoffID),
+		PaymentAmount:   p.Amount,
+		PaymentCurrency: p.Currency.Code,
+	}
+	if len(transactions) == 0 {
+		d := base
+		d.Kind = DiscrepancyMissingInReport
+		return []Discrepancy{d}
+	}
+
+	var ds []Discrepancy
+	var settled *funnelfox.Transaction
+	var statuses []string // 非退款交易的状态
+	refundedInReport := false
+	for i := range transactions {
+		t := &transactions[i]
+		if t.IsRefund() {
+			// 退款交易的状态也可能是 settled，不能作为扣款核对金额
+			refundedInReport = true
+			continue
+		}
+		if !slices.Contains(statuses, t.Status) {
+			statuses = append(statuses, t.Status)
+		}
+		if t.IsSettled() && settled == nil {
+			settled = t
+		}
+	}
+	if settled == nil {
+		d := base
+		d.Kind = DiscrepancyNotSettledInReport
+		d.Detail = "report statuses: " + strings.Join(statuses, ",")
+		ds = append(ds, d)
+	} else {
+		base.ReportAmount = settled.Amount
+		base.ReportCurrency = settled.CurrencyCode
+		if !strings.EqualFold(p.Currency.Code, settled.CurrencyCode) {
+			d := base
+			d.Kind = DiscrepancyCurrencyMismatch
+			ds = append(ds, d)
+		} else if !sameAmount(p.Amount, settled.Amount) {
+			d := base
+			d.Kind = DiscrepancyAmountMismatch
+			ds = append(ds, d)
+		}
+	}
+
+	refundedInHistory := isPositiveAmount(p.Refunded)
+	switch {
+	case refundedInHistory && !refundedInReport:
+		d := base
+		d.Kind = DiscrepancyRefundMissingInReport
+		d.Detail = fmt.Sprintf("refunded %s", p.Refunded)
+		ds = append(ds, d)
+	case refundedInReport && !refundedInHistory:
+		d := base
+		d.Kind = DiscrepancyRefundMissingInHistory
+		ds = append(ds, d)
+	}
+	return ds
+}
+
+// sameAmount 按数值比较金额，无法解析时按字符串比较
+func sameAmount(a, b string) bool {
+	ra, okA := new(big.Rat).SetString(strings.TrimSpace(a))
+	rb, okB := new(big.Rat).SetString(strings.TrimSpace(b))
+	if !okA || !okB {
+		return a == b
+	}
+	return ra.Cmp(rb) == 0
+}
+
+func isPositiveAmount(s string) bool {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	return ok && r.Sign() > 0
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,247 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// pay 测试用支付记录，minute 为相对 testFrom 的分钟数
+type pay struct {
+	order, amount, currency, refunded, subs string
+	minute                                  int
+}
+
+func (p pay) json() string {
+	at := testFrom.Add(time.Duration(p.minute) * time.Minute)
+	return fmt.Sprintf(`{"order_id":%q,"amount":%q,"currency":{"code":%q},"refunded":%q,"subs_id":%q,"created_at":%q}`,
+		p.order, p.amount, p.currency, p.refunded, p.subs, at.Format("2006-01-02T15:04:05"))
+}
+
+func decodeJSON[T any](t *testing.T, data string) []T {
+	t.Helper()
+	var res []T
+	if err := json.Unmarshal([]byte(data), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return res
+}
+
+func paymentsJSON(ps []pay) string {
+	items := make([]string, 0, len(ps))
+	for _, p := range ps {
+		items = append(items, p.json())
+	}
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+func discrepancyKeys(r *PaymentsReport) []string {
+	keys := make([]string, 0, len(r.Discrepancies))
+	for _, d := range r.Discrepancies {
+		keys = append(keys, string(d.Kind)+":"+d.OrderID)
+	}
+	return keys
+}
+
+func TestComparePayments(t *testing.T) {
+	charge := func(id, order string, minute int) trx {
+		return trx{id: id, order: order, status: "settled", amount: "9.99", currency: "USD", subs: "s1", minute: minute}
+	}
+	refund := func(id, order, amount string, minute int) trx {
+		return trx{id: id, order: order, status: "settled", pspType: "refund", amount: amount, currency: "USD", subs: "s1", minute: minute}
+	}
+	paid := pay{order: "o1", amount: "9.99", currency: "USD", refunded: "0", subs: "s1", minute: 1}
+	refunded := paid
+	refunded.refunded = "9.99"
+
+	tests := []struct {
+		name             string
+		payments         []pay
+		trxs             []trx
+		want             []string
+		wantMatched      int
+		wantPayments     int
+		wantTransactions int
+	}{
+		{
+			name:             "matched",
+			payments:         []pay{paid},
+			trxs:             []trx{charge("t1", "o1", 1)},
+			wantMatched:      1,
+			wantPayments:     1,
+			wantTransactions: 1,
+		},
+		{
+			name:             "same amount in different notation",
+			payments:         []pay{{order: "o1", amount: "9.9", currency: "usd", refunded: "0.00", minute: 1}},
+			trxs:             []trx{{id: "t1", order: "o1", status: "settled", amount: "9.90", currency: "USD", minute: 1}},
+			wantMatched:      1,
+			wantPayments:     1,
+			wantTransactions: 1,
+		},
+		{
+			name:             "amount mismatch",
+			payments:         []pay{paid},
+			trxs:             []trx{{id: "t1", order: "o1", status: "settled", amount: "19.99", currency: "USD", minute: 1}},
+			want:             []string{"amount_mismatch:o1"},
+			wantPayments:     1,
+			wantTransactions: 1,
+		},
+		{
+			name:             "currency mismatch",
+			payments:         []pay{paid},
+			trxs:             []trx{{id: "t1", order: "o1", status: "settled", amount: "9.99", currency: "EUR", minute: 1}},
+			want:             []string{"currency_mismatch:o1"},
+			wantPayments:     1,
+			wantTransactions: 1,
+		},
+		{
+			name:             "settled refund row is not the charge",
+			payments:         []pay{{order: "o1", amount: "9.99", currency: "USD", refunded: "5.00", subs: "s1", minute: 1}},
+			trxs:             []trx{refund("r1", "o1", "5.00", 2), charge("t1", "o1", 1)},
+			wantMatched:      1,
+			wantPayments:     1,
+			wantTransactions: 2,
+		},
+		{
+			name:             "refund missing in report",
+			payments:         []pay{refunded},
+			trxs:             []trx{charge("t1", "o1", 1)},
+			want:             []string{"refund_missing_in_report:o1"},
+			wantPayments:     1,
+			wantTransactions: 1,
+		},
+		{
+			name:             "refund missing in history",
+			payments:         []pay{paid},
+			trxs:             []trx{charge("t1", "o1", 1), refund("r1", "o1", "9.99", 2)},
+			want:             []string{"refund_missing_in_history:o1"},
+			wantPayments:     1,
+			wantTransactions: 2,
+		},
+		{
+			name:             "refund after window confirms refund",
+			payments:         []pay{refunded},
+			trxs:             []trx{charge("t1", "o1", 1), refund("r1", "o1", "9.99", 45)},
+			wantMatched:      1,
+			wantPayments:     1,
+			wantTransactions: 1,
+		},
+		{
+			name:     "missing in history only for settled charges in window",
+			payments: []pay{paid},
+			trxs: []trx{
+				charge("t1", "o1", 1),
+				charge("t2", "o2", 2),
+				refund("r3", "o3", "9.99", 3),
+				{id: "t4", order: "o4", status: "declined", amount: "9.99", currency: "USD", subs: "s1", minute: 4},
+				charge("t5", "o5", 45),
+			},
+			want:             []string{"missing_in_history:o2"},
+			wantMatched:      1,
+			wantPayments:     1,
+			wantTransactions: 4,
+		},
+		{
+			name:     "not settled in report",
+			payments: []pay{paid},
+			trxs: []trx{
+				{id: "t1", order: "o1", status: "declined", amount: "9.99", currency: "USD", subs: "s1", minute: 1},
+				{id: "t2", order: "o1", status: "pending", amount: "9.99", currency: "USD", subs: "s1", minute: 2},
+			},
+			want:             []string{"not_settled_in_report:o1"},
+			wantPayments:     1,
+			wantTransactions: 2,
+		},
+		{
+			name:             "only refund in report",
+			payments:         []pay{refunded},
+			trxs:             []trx{refund("r1", "o1", "9.99", 2)},
+			want:             []string{"not_settled_in_report:o1"},
+			wantPayments:     1,
+			wantTransactions: 1,
+		},
+		{
+			name:         "missing in report",
+			payments:     []pay{paid},
+			want:         []string{"missing_in_report:o1"},
+			wantPayments: 1,
+		},
+		{
+			name: "payments outside window are skipped",
+			payments: []pay{
+				{order: "o0", amount: "9.99", currency: "USD", refunded: "9.99", minute: -10},
+				{order: "o9", amount: "9.99", currency: "USD", minute: 30},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []string
+			for _, tr := range tt.trxs {
+				items = append(items, tr.json())
+			}
+			transactions := decodeJSON[funnelfox.Transaction](t, "["+strings.Join(items, ",")+"]")
+			payments := decodeJSON[funnelfox.Payment](t, paymentsJSON(tt.payments))
+
+			report := ComparePayments(map[string][]funnelfox.Payment{"u1": payments}, transactions, testFrom, testFrom.Add(30*time.Minute))
+
+			assertKeys(t, discrepancyKeys(report), tt.want)
+			if report.Matched != tt.wantMatched || report.Payments != tt.wantPayments || report.Transactions != tt.wantTransactions {
+				t.Errorf("matched/payments/transactions = %d/%d/%d, want %d/%d/%d",
+					report.Matched, report.Payments, report.Transactions,
+					tt.wantMatched, tt.wantPayments, tt.wantTransactions)
+			}
+		})
+	}
+}
+
+func TestReconcilePayments(t *testing.T) {
+	trxs := []trx{
+		{id: "t1", order: "o1", status: "settled", amount: "9.99", currency: "USD", subs: "s1", minute: 1},
+		{id: "t2", order: "o2", status: "settled", amount: "4.99", currency: "USD", subs: "s1", minute: 2},
+		{id: "r1", order: "o1", status: "settled", pspType: "refund", amount: "9.99", currency: "USD", subs: "s1", minute: 45},
+		{id: "t3", order: "o3", status: "settled", amount: "9.99", currency: "USD", subs: "s1", minute: 50},
+	}
+	payments := map[string]string{
+		"u1": paymentsJSON([]pay{
+			{order: "o1", amount: "9.99", currency: "USD", refunded: "9.99", subs: "s1", minute: 1},
+		}),
+	}
+	client := newReportClient(t, trxs, payments)
+
+	report, err := ReconcilePayments(context.Background(), client, []string{"u1"}, testFrom, testFrom.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("ReconcilePayments() error = %v", err)
+	}
+	assertKeys(t, discrepancyKeys(report), []string{"missing_in_history:o2"})
+	if report.Matched != 1 || report.Transactions != 2 {
+		t.Errorf("matched = %d, transactions = %d, want 1, 2", report.Matched, report.Transactions)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded PaymentsReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteJSON output: %v", err)
+	}
+	if decoded.Count(DiscrepancyMissingInHistory) != 1 {
+		t.Errorf("decoded report = %+v", decoded)
+	}
+}
+
+func assertKeys(t *testing.T, got, want []string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("discrepancies = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package reconcile
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/byte-power/funnelfox"
+)
+
+// SyntheticEventIDPrefix 补齐事件的 EventID 前缀
+const SyntheticEventIDPrefix = "reconcile:"
+
+// IsSynthetic 判断事件是否由 Reconciler 根据交易报告生成
+func IsSynthetic(event *funnelfox.Event) bool {
+	return strings.HasPrefix(event.EventID, SyntheticEventIDPrefix)
+}
+
+// Result 一次补齐的结果
+type Result struct {
+	Transactions     int       // 遍历的交易数量
+	Orders           int       // 有结果（settled/declined）的订单数量
+	AlreadyProcessed int       // 已通过 webhook 处理的订单数量
+	Replayed         int       // 补齐并处理成功的订单数量
+	Failures         []Failure // 处理失败的订单
+}
+
+// Failure 处理失败的订单
+type Failure struct {
+	OrderID string
+	Subtype funnelfox.EventSubtype
+	Err     error
+}
+
+// Reconciler 根据交易报告补齐缺失的订单事件
+type Reconciler struct {
+	client   *funnelfox.Client
+	store    DedupStore
+	handle   func(ctx context.Context, event *funnelfox.Event) error
+	logger   funnelfox.Logger
+	pageSize int
+}
+
+// NewReconciler 创建 Reconciler
+// handle: 与 webhook 相同的事件处理函数，补齐的事件处理成功后写入 store
+// logger: 日志记录器，可以为 nil（使用 NopLogger）
+func NewReconciler(client *funnelfox.Client, store DedupStore, handle func(ctx context.Context, event *funnelfox.Event) error, logger funnelfox.Logger) *Reconciler {
+	if logger == nil {
+		logger = &funnelfox.NopLogger{}
+	}
+	return &Reconciler{
+		client: client,
+		store:  store,
+		handle: handle,
+		logger: logger,
+	}
+}
+
+// SetPageSize 设置每次请求交易报告的数量（1-500），默认使用 API 默认值
+func (r *Reconciler) SetPageSize(n int) {
+	r.pageSize = n
+}
+
+// Run 遍历 [from, to) 内的交易，为没有处理记录的订单生成 order settled/declined 事件并交给处理函数
+//
+// 同一订单有多笔交易时，存在成功交易则生成 settled 事件，否则以最后一笔失败交易生成 declined 事件；
+// 退款交易不参与补齐。
+// 单个订单处理失败不会中断补齐，失败记录在 Result.Failures 中；交易报告请求失败时返回错误。
+func (r *Reconciler) Run(ctx context.Context, from, to time.Time) (*Result, error) {
+	req := funnelfox.TransactionReportRequest{LastTransactionDate: from}
+	if r.pageSize > 0 {
+		size := r.pageSize
+		req.Limit = &size
+	}
+
+	res := &Result{}
+	outcomes := make(map[string]*funnelfox.Transaction)
+	var orderIDs []string
+	for t, err := range r.client.WithContext(ctx).Transactions(req) {
+		if err != nil {
+			return res, err
+		}
+		if t.TrxCreatedAt != nil {
+			if !t.TrxCreatedAt.Before(to) {
+				break
+			}
+			if t.TrxCreatedAt.Before(from) {
+				continue
+			}
+		}
+		res.Transactions++
+		if t.OrderID == "" || transactionSubtype(&t) == "" {
+			continue
+		}
+		prev, ok := outcomes[t.OrderID]
+		if !ok {
+			orderIDs = append(orderIDs, t.OrderID)
+		}
+		// 成功交易优先，否则保留最后一笔失败交易
+		if !ok || transactionSubtype(prev) != funnelfox.EventSubtypeOrderSettled {
+			outcomes[t.OrderID] = &t
+		}
+	}
+
+	res.Orders = len(orderIDs)
+	for _, orderID := range orderIDs {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		t := outcomes[orderID]
+		subtype := transactionSubtype(t)
+		processed, err := r.store.IsProcessed(ctx, orderID, subtype)
+		if err != nil {
+			return res, err
+		}
+		if processed {
+			res.AlreadyProcessed++
+			continue
+		}
+
+		event := SyntheticOrderEvent(t)
+		if err := r.handle(ctx, event); err != nil {
+			r.logger.Error("funnelfox_reconcile_handle_error",
+				funnelfox.String("order_id", orderID),
+				funnelfox.String("subtype", string(subtype)),
+				funnelfox.ErrorField(err))
+			res.Failures = append(res.Failures, Failure{OrderID: orderID, Subtype: subtype, Err: err})
+			continue
+		}
+		if err := r.store.MarkProcessed(ctx, orderID, subtype); err != nil {
+			return res, err
+		}
+		r.logger.Info("funnelfox_reconcile_replayed",
+			funnelfox.String("order_id", orderID),
+			funnelfox.String("subtype", string(subtype)))
+		res.Replayed++
+	}
+	return res, nil
+}
+
+// transactionSubtype 交易对应的订单事件子类型，非最终状态和退款交易返回空
+func transactionSubtype(t *funnelfox.Transaction) funnelfox.EventSubtype {
+	switch {
+	case t.IsRefund():
+		// 退款交易的状态也可能是 settled，不能当作订单成功补齐
+		return ""
+	case t.IsSettled():
+		return funnelfox.EventSubtypeOrderSettled
+	case t.IsDeclined():
+		return funnelfox.EventSubtypeOrderDeclined
+	}
+	return ""
+}
+
+// SyntheticOrderEvent 根据交易生成订单事件
+//
+// 交易报告不包含用户信息，生成的事件 ExternalID 和 User 为空，
+// 订阅和一次性购买分别通过 Order.SubsID 和 Order.OneoffID 关联
+func SyntheticOrderEvent(t *funnelfox.Transaction) *funnelfox.Event {
+	subtype := transactionSubtype(t)
+	order := &funnelfox.Order{
+		OrderField: funnelfox.OrderField{
+			OrderID:      t.OrderID,
+			Amount:       t.Amount,
+			CurrencyCode: t.CurrencyCode,
+			SubsID:       t.MetaSubsID,
+			OneoffID:     t.MetaOneoffID,
+			Status:       funnelfox.OrderStatus(strings.ToLower(t.Status)),
+		},
+		CreatedAt: t.TrxCreatedAt,
+	}
+	if t.PSP != "" {
+		psp := t.PSP
+		order.PSP = &psp
+	}
+	if t.PSPReasonMessage != nil {
+		var reason any = *t.PSPReasonMessage
+		order.DeclineReason = &reason
+	}
+	if t.MetaRetryStep != nil {
+		var step any = *t.MetaRetryStep
+		order.RetryStep = &step
+	}
+
+	event := &funnelfox.Event{
+		EventID:   SyntheticEventIDPrefix + t.OrderID + ":" + string(subtype),
+		EventType: funnelfox.EventTypeOrder,
+		Subtype:   subtype,
+		Order:     order,
+	}
+	if t.TrxCreatedAt != nil {
+		event.EventTimestamp = *t.TrxCreatedAt
+	}
+	return event
+}
@@ -0,0 +1,257 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byte-power/funnelfox"
+)
+
+var testFrom = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+// roundTripFunc 将函数适配为 http.RoundTripper，测试中代替真实的 API
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// trx 测试用交易，minute 为相对 testFrom 的分钟数
+type trx struct {
+	id, order, status, pspType, amount, currency, subs string
+	minute                                             int
+}
+
+func (t trx) json() string {
+	at := testFrom.Add(time.Duration(t.minute) * time.Minute)
+	subs := "null"
+	if t.subs != "" {
+		subs = strconv.Quote(t.subs)
+	}
+	return fmt.Sprintf(`{"trx_id":%q,"order_id":%q,"status":%q,"psp_transaction_type":%q,"amount":%q,"currency_code":%q,"meta_subs_id":%s,"trx_created_at":%q}`,
+		t.id, t.order, t.status, t.pspType, t.amount, t.currency, subs, at.Format("2006-01-02T15:04:05"))
+}
+
+// newReportClient 返回的客户端从 trxs 中响应 /transaction_report（一次返回所有不早于游标的交易，支持 order_id 过滤），
+// 从 payments（外部用户ID -> payments 数组 JSON）中响应 /payments_history
+func newReportClient(t *testing.T, trxs []trx, payments map[string]string) *funnelfox.Client {
+	t.Helper()
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var req struct {
+			LastTransactionDate time.Time `json:"last_transaction_date"`
+			OrderID             string    `json:"order_id"`
+			ExternalID          string    `json:"external_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/transaction_report"):
+			var items []string
+			for _, tr := range trxs {
+				if req.OrderID != "" && tr.order != req.OrderID {
+					continue
+				}
+				if !testFrom.Add(time.Duration(tr.minute) * time.Minute).Before(req.LastTransactionDate) {
+					items = append(items, tr.json())
+				}
+			}
+			return jsonResponse(`{"status":"success","data":{"transactions":[` + strings.Join(items, ",") + `]}}`), nil
+		case strings.HasSuffix(r.URL.Path, "/payments_history"):
+			list := payments[req.ExternalID]
+			if list == "" {
+				list = "[]"
+			}
+			return jsonResponse(`{"status":"success","data":{"payments":` + list + `}}`), nil
+		}
+		t.Fatalf("unexpected request %s", r.URL.Path)
+		return nil, nil
+	})
+	return funnelfox.NewClientWithHTTPClient("org", "secret", &http.Client{Transport: rt}, nil)
+}
+
+func TestReconcilerRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		trxs      []trx
+		processed []string // orderID:subtype
+		failOn    string
+		want      []string
+		wantRes   Result
+	}{
+		{
+			name: "missing settled and declined orders",
+			trxs: []trx{
+				{id: "t1", order: "o1", status: "settled", minute: 1},
+				{id: "t2", order: "o2", status: "declined", minute: 2},
+			},
+			want:    []string{"o1:settled", "o2:declined"},
+			wantRes: Result{Transactions: 2, Orders: 2, Replayed: 2},
+		},
+		{
+			name: "already processed via webhook",
+			trxs: []trx{
+				{id: "t1", order: "o1", status: "settled", minute: 1},
+				{id: "t2", order: "o2", status: "settled", minute: 2},
+			},
+			processed: []string{"o1:settled"},
+			want:      []string{"o2:settled"},
+			wantRes:   Result{Transactions: 2, Orders: 2, AlreadyProcessed: 1, Replayed: 1},
+		},
+		{
+			name: "retry after decline replays settled",
+			trxs: []trx{
+				{id: "t1", order: "o1", status: "declined", minute: 1},
+				{id: "t2", order: "o1", status: "settled", minute: 2},
+				{id: "t3", order: "o1", status: "declined", minute: 3},
+			},
+			processed: []string{"o1:declined"},
+			want:      []string{"o1:settled"},
+			wantRes:   Result{Transactions: 3, Orders: 1, Replayed: 1},
+		},
+		{
+			name: "settled refund rows are not replayed",
+			trxs: []trx{
+				{id: "t1", order: "o1", status: "settled", minute: 1},
+				{id: "r1", order: "o1", status: "settled", pspType: "refund", minute: 2},
+				{id: "r2", order: "o2", status: "settled", pspType: "refund", minute: 3},
+				{id: "r3", order: "o3", status: "refunded", minute: 4},
+			},
+			want:    []string{"o1:settled"},
+			wantRes: Result{Transactions: 4, Orders: 1, Replayed: 1},
+		},
+		{
+			name: "window excludes later transactions",
+			trxs: []trx{
+				{id: "t1", order: "o1", status: "settled", minute: 1},
+				{id: "t2", order: "o2", status: "settled", minute: 60},
+			},
+			want:    []string{"o1:settled"},
+			wantRes: Result{Transactions: 1, Orders: 1, Replayed: 1},
+		},
+		{
+			name: "handler failure is recorded",
+			trxs: []trx{
+				{id: "t1", order: "o1", status: "settled", minute: 1},
+				{id: "t2", order: "o2", status: "settled", minute: 2},
+			},
+			failOn:  "o1",
+			want:    []string{"o1:settled", "o2:settled"},
+			wantRes: Result{Transactions: 2, Orders: 2, Replayed: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryDedupStore()
+			for _, p := range tt.processed {
+				order, subtype, _ := strings.Cut(p, ":")
+				store.MarkProcessed(ctx, order, funnelfox.EventSubtype(subtype))
+			}
+
+			errHandle := errors.New("handler failed")
+			var got []string
+			r := NewReconciler(newReportClient(t, tt.trxs, nil), store, func(ctx context.Context, e *funnelfox.Event) error {
+				if !IsSynthetic(e) || e.EventType != funnelfox.EventTypeOrder {
+					t.Errorf("event %s is not a synthetic order event", e.EventID)
+				}
+				got = append(got, e.Order.OrderID+":"+string(e.Subtype))
+				if e.Order.OrderID == tt.failOn {
+					return errHandle
+				}
+				return nil
+			}, nil)
+
+			res, err := r.Run(ctx, testFrom, testFrom.Add(30*time.Minute))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("replayed = %v, want %v", got, tt.want)
+			}
+			failures := res.Failures
+			res.Failures = nil
+			if !reflect.DeepEqual(*res, tt.wantRes) {
+				t.Errorf("result = %+v, want %+v", *res, tt.wantRes)
+			}
+			if tt.failOn != "" {
+				if len(failures) != 1 || failures[0].OrderID != tt.failOn || !errors.Is(failures[0].Err, errHandle) {
+					t.Errorf("failures = %+v", failures)
+				}
+				ok, _ := store.IsProcessed(ctx, tt.failOn, funnelfox.EventSubtypeOrderSettled)
+				if ok {
+					t.Error("failed order marked as processed")
+				}
+			}
+
+			// 再次运行时所有成功补齐的订单都已处理
+			got = nil
+			if _, err := r.Run(ctx, testFrom, testFrom.Add(30*time.Minute)); err != nil {
+				t.Fatalf("second Run() error = %v", err)
+			}
+			var retried []string
+			if tt.failOn != "" {
+				retried = []string{tt.failOn + ":settled"}
+			}
+			if !slices.Equal(got, retried) {
+				t.Errorf("second run replayed = %v, want %v", got, retried)
+			}
+		})
+	}
+}
+
+func TestTrackOrders(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     *funnelfox.Event
+		handleErr error
+		want      bool
+	}{
+		{
+			name:  "order settled",
+			event: &funnelfox.Event{EventType: funnelfox.EventTypeOrder, Subtype: funnelfox.EventSubtypeOrderSettled, Order: &funnelfox.Order{OrderField: funnelfox.OrderField{OrderID: "o1"}}},
+			want:  true,
+		},
+		{
+			name:      "handler failed",
+			event:     &funnelfox.Event{EventType: funnelfox.EventTypeOrder, Subtype: funnelfox.EventSubtypeOrderSettled, Order: &funnelfox.Order{OrderField: funnelfox.OrderField{OrderID: "o1"}}},
+			handleErr: errors.New("boom"),
+		},
+		{
+			name:  "subscription event",
+			event: &funnelfox.Event{EventType: funnelfox.EventTypeSubscription, Subtype: funnelfox.EventSubtypeOrderSettled},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryDedupStore()
+			handle := TrackOrders(store, func(ctx context.Context, e *funnelfox.Event) error { return tt.handleErr })
+			if err := handle(ctx, tt.event); !errors.Is(err, tt.handleErr) {
+				t.Fatalf("handle() = %v, want %v", err, tt.handleErr)
+			}
+			got, _ := store.IsProcessed(ctx, "o1", funnelfox.EventSubtypeOrderSettled)
+			if got != tt.want {
+				t.Errorf("processed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
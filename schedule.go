@@ -0,0 +1,109 @@
+package funnelfox
+
+import (
+	"time"
+)
+
+// ChargeKind 扣费类型
+type ChargeKind string
+
+const (
+	ChargeKindPaidTrial ChargeKind = "paid_trial"
+	ChargeKindRecurring ChargeKind = "recurring"
+	ChargeKindLifetime  ChargeKind = "lifetime"
+)
+
+// ChargeEvent 一次预计扣费
+type ChargeEvent struct {
+	Iteration    int        `json:"iteration"` // 第几次扣费，从 1 开始
+	At           time.Time  `json:"at"`
+	Amount       string     `json:"amount"`
+	CurrencyCode string     `json:"currency_code"`
+	Kind         ChargeKind `json:"kind"`
+}
+
+// BillingSchedule 计算从 start 开始购买价格点 pp 后的前 n 次扣费
+// 免费试用期间不扣费；按月、按年的周期以续费起点的日期为锚点，遇到月末自动截断（如 1 月 31 日 -> 2 月 28 日 -> 3 月 31 日）
+func BillingSchedule(pp PricePoint, start time.Time, n int) ([]ChargeEvent, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	currency := pp.Currency.Code
+	var events []ChargeEvent
+	add := func(at time.Time, amount string, kind ChargeKind) {
+		events = append(events, ChargeEvent{
+			Iteration:    len(events) + 1,
+			At:           at,
+			Amount:       amount,
+			CurrencyCode: currency,
+			Kind:         kind,
+		})
+	}
+
+	if pp.NextPrice == nil || pp.NextPeriod == nil || pp.NextPeriodDuration == nil {
+		if pp.LifetimePrice == nil {
+			return nil, NewError("price point " + pp.Ident + " has neither lifetime nor recurring price")
+		}
+		add(start, *pp.LifetimePrice, ChargeKindLifetime)
+		return events, nil
+	}
+
+	recurringStart := start
+	switch pp.IntroType {
+	case IntroTypeFreeTrial:
+		if pp.IntroFreeTrialPeriod == nil || pp.IntroFreeTrialPeriodDuration == nil {
+			return nil, NewError("price point " + pp.Ident + " has free trial without period")
+		}
+		if *pp.IntroFreeTrialPeriod <= 0 || !isValidPeriodDurationUnit(*pp.IntroFreeTrialPeriodDuration) {
+			return nil, NewError("price point " + pp.Ident + " has invalid free trial period")
+		}
+		recurringStart = addPeriods(start, *pp.IntroFreeTrialPeriod, *pp.IntroFreeTrialPeriodDuration)
+	case IntroTypePaidTrial:
+		if pp.IntroPaidTrialPrice == nil || pp.IntroPaidTrialPeriod == nil || pp.IntroPaidTrialPeriodDuration == nil {
+			return nil, NewError("price point " + pp.Ident + " has paid trial without price or period")
+		}
+		if *pp.IntroPaidTrialPeriod <= 0 || !isValidPeriodDurationUnit(*pp.IntroPaidTrialPeriodDuration) {
+			return nil, NewError("price point " + pp.Ident + " has invalid paid trial period")
+		}
+		add(start, *pp.IntroPaidTrialPrice, ChargeKindPaidTrial)
+		recurringStart = addPeriods(start, *pp.IntroPaidTrialPeriod, *pp.IntroPaidTrialPeriodDuration)
+	}
+
+	if *pp.NextPeriod <= 0 || !isValidPeriodDurationUnit(*pp.NextPeriodDuration) {
+		return nil, NewError("price point " + pp.Ident + " has invalid next period")
+	}
+	for i := 0; len(events) < n; i++ {
+		at := addPeriods(recurringStart, i**pp.NextPeriod, *pp.NextPeriodDuration)
+		add(at, *pp.NextPrice, ChargeKindRecurring)
+	}
+	return events, nil
+}
+
+// addPeriods 在 t 上增加 count 个 unit，按月、按年增加时截断到目标月份的最后一天
+func addPeriods(t time.Time, count int, unit PeriodDurationUnit) time.Time {
+	switch unit {
+	case PeriodDurationUnitMinutes:
+		return t.Add(time.Duration(count) * time.Minute)
+	case PeriodDurationUnitDays:
+		return t.AddDate(0, 0, count)
+	case PeriodDurationUnitWeeks:
+		return t.AddDate(0, 0, 7*count)
+	case PeriodDurationUnitMonths:
+		return addMonthsClamped(t, count)
+	case PeriodDurationUnitYears:
+		return addMonthsClamped(t, 12*count)
+	}
+	return t
+}
+
+// addMonthsClamped 增加 months 个月，日期超过目标月份天数时取该月最后一天
+func addMonthsClamped(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	// 目标月份的第一天，AddDate 在日期为 1 时不会溢出
+	first := time.Date(year, month, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location()).AddDate(0, months, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
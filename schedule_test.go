@@ -0,0 +1,115 @@
+package funnelfox
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBillingSchedule(t *testing.T) {
+	start := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		pp      string
+		n       int
+		want    []string // kind@time amount
+		wantErr bool
+	}{
+		{
+			name: "monthly clamps to month end",
+			pp:   `{"ident":"m","currency":{"code":"USD"},"intro_type":"no_intro","next_price":"9.99","next_period":1,"next_period_duration":"months"}`,
+			n:    3,
+			want: []string{
+				"recurring@2024-01-31T12:00:00Z 9.99",
+				"recurring@2024-02-29T12:00:00Z 9.99",
+				"recurring@2024-03-31T12:00:00Z 9.99",
+			},
+		},
+		{
+			name: "free trial",
+			pp:   `{"ident":"ft","currency":{"code":"USD"},"intro_type":"free_trial","intro_free_trial_period":7,"intro_free_trial_period_duration":"days","next_price":"4.99","next_period":1,"next_period_duration":"weeks"}`,
+			n:    2,
+			want: []string{
+				"recurring@2024-02-07T12:00:00Z 4.99",
+				"recurring@2024-02-14T12:00:00Z 4.99",
+			},
+		},
+		{
+			name: "paid trial",
+			pp:   `{"ident":"pt","currency":{"code":"USD"},"intro_type":"paid_trial","intro_paid_trial_price":"0.99","intro_paid_trial_period":3,"intro_paid_trial_period_duration":"days","next_price":"29.99","next_period":1,"next_period_duration":"years"}`,
+			n:    3,
+			want: []string{
+				"paid_trial@2024-01-31T12:00:00Z 0.99",
+				"recurring@2024-02-03T12:00:00Z 29.99",
+				"recurring@2025-02-03T12:00:00Z 29.99",
+			},
+		},
+		{
+			name: "lifetime",
+			pp:   `{"ident":"lt","currency":{"code":"USD"},"lifetime_price":"99"}`,
+			n:    5,
+			want: []string{"lifetime@2024-01-31T12:00:00Z 99"},
+		},
+		{
+			name: "zero charges",
+			pp:   `{"ident":"m","currency":{"code":"USD"},"next_price":"9.99","next_period":1,"next_period_duration":"months"}`,
+			n:    0,
+		},
+		{
+			name:    "unknown free trial unit",
+			pp:      `{"ident":"ft","currency":{"code":"USD"},"intro_type":"free_trial","intro_free_trial_period":7,"intro_free_trial_period_duration":"fortnights","next_price":"4.99","next_period":1,"next_period_duration":"weeks"}`,
+			n:       2,
+			wantErr: true,
+		},
+		{
+			name:    "zero free trial period",
+			pp:      `{"ident":"ft","currency":{"code":"USD"},"intro_type":"free_trial","intro_free_trial_period":0,"intro_free_trial_period_duration":"days","next_price":"4.99","next_period":1,"next_period_duration":"weeks"}`,
+			n:       2,
+			wantErr: true,
+		},
+		{
+			name:    "unknown paid trial unit",
+			pp:      `{"ident":"pt","currency":{"code":"USD"},"intro_type":"paid_trial","intro_paid_trial_price":"0.99","intro_paid_trial_period":3,"intro_paid_trial_period_duration":"","next_price":"29.99","next_period":1,"next_period_duration":"years"}`,
+			n:       2,
+			wantErr: true,
+		},
+		{
+			name:    "free trial without period",
+			pp:      `{"ident":"ft","currency":{"code":"USD"},"intro_type":"free_trial","next_price":"4.99","next_period":1,"next_period_duration":"weeks"}`,
+			n:       2,
+			wantErr: true,
+		},
+		{
+			name:    "unknown next unit",
+			pp:      `{"ident":"m","currency":{"code":"USD"},"next_price":"9.99","next_period":1,"next_period_duration":"fortnights"}`,
+			n:       1,
+			wantErr: true,
+		},
+		{
+			name:    "no price",
+			pp:      `{"ident":"none","currency":{"code":"USD"}}`,
+			n:       1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pp PricePoint
+			if err := json.Unmarshal([]byte(tt.pp), &pp); err != nil {
+				t.Fatal(err)
+			}
+			events, err := BillingSchedule(pp, start, tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := make([]string, 0, len(events))
+			for i, e := range events {
+				if e.Iteration != i+1 || e.CurrencyCode != "USD" {
+					t.Errorf("event %d = %+v", i, e)
+				}
+				got = append(got, string(e.Kind)+"@"+e.At.Format(time.RFC3339)+" "+e.Amount)
+			}
+			assertStrings(t, "events", got, tt.want)
+		})
+	}
+}
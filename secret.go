@@ -0,0 +1,146 @@
+package funnelfox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretProvider 密钥提供者，每次需要密钥的请求都会调用
+type SecretProvider interface {
+	SecretKey(ctx context.Context) (string, error)
+}
+
+// SecretRefresher 可选接口：鉴权失败时强制重新获取密钥
+type SecretRefresher interface {
+	RefreshSecretKey(ctx context.Context) (string, error)
+}
+
+// StaticSecret 固定密钥
+type StaticSecret string
+
+func (s StaticSecret) SecretKey(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// EnvSecret 从环境变量读取密钥，值为环境变量名
+type EnvSecret string
+
+func (e EnvSecret) SecretKey(ctx context.Context) (string, error) {
+	key := os.Getenv(string(e))
+	if key == "" {
+		return "", fmt.Errorf("environment variable %s is empty", string(e))
+	}
+	return key, nil
+}
+
+// FileSecret 从文件读取密钥，并定期检查文件变化
+type FileSecret struct {
+	path     string
+	mu       sync.RWMutex
+	key      string
+	modTime  time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewFileSecret 读取 path 中的密钥（去除首尾空白）
+// interval > 0 时每隔 interval 检查文件修改时间，变化后重新加载；使用完需要调用 Close
+func NewFileSecret(path string, interval time.Duration) (*FileSecret, error) {
+	f := &FileSecret{
+		path: path,
+		stop: make(chan struct{}),
+	}
+	if _, err := f.load(); err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go f.watch(interval)
+	}
+	return f, nil
+}
+
+func (f *FileSecret) SecretKey(ctx context.Context) (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.key, nil
+}
+
+func (f *FileSecret) RefreshSecretKey(ctx context.Context) (string, error) {
+	return f.load()
+}
+
+// Close 停止检查文件变化
+func (f *FileSecret) Close() error {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+	return nil
+}
+
+func (f *FileSecret) load() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", err
+	}
+	bs, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(string(bs))
+	if key == "" {
+		return "", fmt.Errorf("secret file %s is empty", f.path)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.key = key
+	f.modTime = info.ModTime()
+	return key, nil
+}
+
+func (f *FileSecret) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(f.path)
+			if err != nil {
+				continue
+			}
+			f.mu.RLock()
+			changed := !info.ModTime().Equal(f.modTime)
+			f.mu.RUnlock()
+			if changed {
+				// 文件可能正在写入，加载失败时保留旧密钥，下次再试
+				_, _ = f.load()
+			}
+		}
+	}
+}
+
+// SetSecretProvider 设置密钥提供者，替换构造时传入的 secretKey
+func (c *Client) SetSecretProvider(p SecretProvider) {
+	if p == nil {
+		p = StaticSecret("")
+	}
+	c.secrets = p
+}
+
+// SetRetryOnAuthFailure 设置鉴权失败（HTTP 401/403）时是否刷新密钥并重试一次，刷新后密钥不变时不重试
+func (c *Client) SetRetryOnAuthFailure(retry bool) {
+	c.retryOnAuthFailure = retry
+}
+
+// refreshSecretKey 鉴权失败后获取新密钥：提供者实现 SecretRefresher 时强制刷新，否则重新调用 SecretKey
+func (c *Client) refreshSecretKey(ctx context.Context) (string, error) {
+	if r, ok := c.secrets.(SecretRefresher); ok {
+		return r.RefreshSecretKey(ctx)
+	}
+	return c.secrets.SecretKey(ctx)
+}
@@ -0,0 +1,206 @@
+package funnelfox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSecretProviders(t *testing.T) {
+	t.Setenv("FUNNELFOX_TEST_SECRET", "env-key")
+	t.Setenv("FUNNELFOX_TEST_EMPTY", "")
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("  file-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fileSecret, err := NewFileSecret(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fileSecret.Close()
+
+	tests := []struct {
+		name     string
+		provider SecretProvider
+		want     string
+		wantErr  bool
+	}{
+		{"static", StaticSecret("static-key"), "static-key", false},
+		{"env", EnvSecret("FUNNELFOX_TEST_SECRET"), "env-key", false},
+		{"env empty", EnvSecret("FUNNELFOX_TEST_EMPTY"), "", true},
+		{"file trims whitespace", fileSecret, "file-key", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.provider.SecretKey(context.Background())
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("got (%q, %v), want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFileSecretErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte(" \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(dir, "missing"), empty} {
+		if _, err := NewFileSecret(path, 0); err == nil {
+			t.Errorf("NewFileSecret(%s) should fail", path)
+		}
+	}
+}
+
+func TestFileSecretWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFileSecret(path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := os.WriteFile(path, []byte("new"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// 修改时间精度较低的文件系统上 mtime 可能不变，显式调整
+	_ = os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		key, _ := f.SecretKey(context.Background())
+		if key == "new" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("key = %q after reload, want new", key)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rotatingSecret 每次刷新后返回下一个密钥
+type rotatingSecret struct {
+	keys       []string
+	refreshErr error
+	i          int
+}
+
+func (r *rotatingSecret) SecretKey(ctx context.Context) (string, error) {
+	return r.keys[r.i], nil
+}
+
+func (r *rotatingSecret) RefreshSecretKey(ctx context.Context) (string, error) {
+	if r.refreshErr != nil {
+		return "", r.refreshErr
+	}
+	r.i++
+	return r.keys[r.i], nil
+}
+
+type failingSecret struct{}
+
+func (failingSecret) SecretKey(ctx context.Context) (string, error) {
+	return "", errors.New("vault unavailable")
+}
+
+func TestRetryOnAuthFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    bool
+		provider SecretProvider
+		statuses []int
+		wantKeys []string
+		wantErr  bool
+	}{
+		{
+			name:     "retry with refreshed key",
+			retry:    true,
+			provider: &rotatingSecret{keys: []string{"old", "new"}},
+			statuses: []int{http.StatusUnauthorized, http.StatusOK},
+			wantKeys: []string{"old", "new"},
+		},
+		{
+			name:     "retry only once",
+			retry:    true,
+			provider: &rotatingSecret{keys: []string{"old", "new"}},
+			statuses: []int{http.StatusForbidden, http.StatusForbidden},
+			wantKeys: []string{"old", "new"},
+			wantErr:  true,
+		},
+		{
+			name:     "retry disabled",
+			provider: &rotatingSecret{keys: []string{"old", "new"}},
+			statuses: []int{http.StatusUnauthorized},
+			wantKeys: []string{"old"},
+			wantErr:  true,
+		},
+		{
+			name:     "no retry on other errors",
+			retry:    true,
+			provider: &rotatingSecret{keys: []string{"old", "new"}},
+			statuses: []int{http.StatusInternalServerError},
+			wantKeys: []string{"old"},
+			wantErr:  true,
+		},
+		{
+			name:     "refresh failure",
+			retry:    true,
+			provider: &rotatingSecret{keys: []string{"old"}, refreshErr: errors.New("refresh failed")},
+			statuses: []int{http.StatusUnauthorized},
+			wantKeys: []string{"old"},
+			wantErr:  true,
+		},
+		{
+			name:     "static secret is not retried",
+			retry:    true,
+			provider: StaticSecret("old"),
+			statuses: []int{http.StatusUnauthorized},
+			wantKeys: []string{"old"},
+			wantErr:  true,
+		},
+		{
+			name:     "refresh returns same key",
+			retry:    true,
+			provider: &rotatingSecret{keys: []string{"old", "old"}},
+			statuses: []int{http.StatusUnauthorized},
+			wantKeys: []string{"old"},
+			wantErr:  true,
+		},
+		{
+			name:     "provider error",
+			provider: failingSecret{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				keys = append(keys, req.Header.Get("ff-secret-key"))
+				status := tt.statuses[len(keys)-1]
+				if status == http.StatusOK {
+					return jsonResponse(status, okResponse), nil
+				}
+				return jsonResponse(status, `{"status":"error","error":[{"type":"auth","msg":"denied"}]}`), nil
+			})
+			c.SetSecretProvider(tt.provider)
+			c.SetRetryOnAuthFailure(tt.retry)
+			err := c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			assertStrings(t, "keys", keys, tt.wantKeys)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package funnelfox
+
+import "slices"
+
+// SubscriptionStatus 订阅状态
+//
+// 取值来自 webhook 和 my_assets 响应中观测到的值，API 文档没有给出完整枚举，
+// 可能出现 SDK 未定义的状态（见 IsKnown 和 SubscriptionField.UnknownStatuses）
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive            SubscriptionStatus = "active"
+	SubscriptionStatusTrial             SubscriptionStatus = "trial"
+	SubscriptionStatusGrace             SubscriptionStatus = "grace"
+	SubscriptionStatusRetry             SubscriptionStatus = "retry"
+	SubscriptionStatusPaused            SubscriptionStatus = "paused"
+	SubscriptionStatusDeferred          SubscriptionStatus = "deferred"
+	SubscriptionStatusAutorenewDisabled SubscriptionStatus = "autorenew_disabled"
+	SubscriptionStatusCancelled         SubscriptionStatus = "cancelled"
+	SubscriptionStatusExpired           SubscriptionStatus = "expired"
+)
+
+var knownSubscriptionStatuses = []SubscriptionStatus{
+	SubscriptionStatusActive,
+	SubscriptionStatusTrial,
+	SubscriptionStatusGrace,
+	SubscriptionStatusRetry,
+	SubscriptionStatusPaused,
+	SubscriptionStatusDeferred,
+	SubscriptionStatusAutorenewDisabled,
+	SubscriptionStatusCancelled,
+	SubscriptionStatusExpired,
+}
+
+// IsKnown 是否为 SDK 已定义的状态
+func (s SubscriptionStatus) IsKnown() bool {
+	return slices.Contains(knownSubscriptionStatuses, s)
+}
+
+// SubscriptionAction 订阅当前可执行的操作
+//
+// 与 SubscriptionStatus 一样来自观测到的 available_actions 取值，不是 API 文档给出的完整枚举
+type SubscriptionAction string
+
+const (
+	ActionPause            SubscriptionAction = "pause"
+	ActionResume           SubscriptionAction = "resume"
+	ActionDefer            SubscriptionAction = "defer"
+	ActionEnableAutorenew  SubscriptionAction = "enable_autorenew"
+	ActionDisableAutorenew SubscriptionAction = "disable_autorenew"
+	ActionMigrate          SubscriptionAction = "migration"
+	ActionDiscount         SubscriptionAction = "discount"
+)
+
+var knownSubscriptionActions = []SubscriptionAction{
+	ActionPause,
+	ActionResume,
+	ActionDefer,
+	ActionEnableAutorenew,
+	ActionDisableAutorenew,
+	ActionMigrate,
+	ActionDiscount,
+}
+
+// IsKnown 是否为 SDK 已定义的操作
+func (a SubscriptionAction) IsKnown() bool {
+	return slices.Contains(knownSubscriptionActions, a)
+}
+
+// HasStatus 订阅是否处于状态 status
+func (s SubscriptionField) HasStatus(status SubscriptionStatus) bool {
+	return slices.Contains(s.Status, status)
+}
+
+// Can 订阅当前是否允许执行 action
+func (s SubscriptionField) Can(action SubscriptionAction) bool {
+	return slices.Contains(s.AvailableActions, action)
+}
+
+// IsInGrace 是否处于宽限期
+func (s SubscriptionField) IsInGrace() bool {
+	return s.HasStatus(SubscriptionStatusGrace)
+}
+
+// IsInTrial 是否处于试用期
+func (s SubscriptionField) IsInTrial() bool {
+	return s.HasStatus(SubscriptionStatusTrial)
+}
+
+// IsPaused 是否已暂停
+func (s SubscriptionField) IsPaused() bool {
+	return s.HasStatus(SubscriptionStatusPaused)
+}
+
+// UnknownStatuses 返回 SDK 未定义的状态
+func (s SubscriptionField) UnknownStatuses() []SubscriptionStatus {
+	var unknown []SubscriptionStatus
+	for _, status := range s.Status {
+		if !status.IsKnown() {
+			unknown = append(unknown, status)
+		}
+	}
+	return unknown
+}
+
+// UnknownActions 返回 SDK 未定义的操作
+func (s SubscriptionField) UnknownActions() []SubscriptionAction {
+	var unknown []SubscriptionAction
+	for _, action := range s.AvailableActions {
+		if !action.IsKnown() {
+			unknown = append(unknown, action)
+		}
+	}
+	return unknown
+}
+
+// logUnknownSubscriptionValues 记录 SDK 未定义的状态和操作，便于及时补充
+func (c *Client) logUnknownSubscriptionValues(sub SubscriptionField) {
+	for _, status := range sub.UnknownStatuses() {
+		c.logger.Info("funnelfox_unknown_subscription_status",
+			String("subs_id", sub.SubsID),
+			String("status", string(status)))
+	}
+	for _, action := range sub.UnknownActions() {
+		c.logger.Info("funnelfox_unknown_subscription_action",
+			String("subs_id", sub.SubsID),
+			String("action", string(action)))
+	}
+}
@@ -0,0 +1,70 @@
+package funnelfox
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSubscriptionFieldHelpers(t *testing.T) {
+	sub := SubscriptionField{
+		SubsID:           "s1",
+		Status:           []SubscriptionStatus{SubscriptionStatusTrial, SubscriptionStatusGrace, "frozen"},
+		AvailableActions: []SubscriptionAction{ActionPause, ActionDefer, "refund_last"},
+	}
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"in trial", sub.IsInTrial(), true},
+		{"in grace", sub.IsInGrace(), true},
+		{"paused", sub.IsPaused(), false},
+		{"has active", sub.HasStatus(SubscriptionStatusActive), false},
+		{"can pause", sub.Can(ActionPause), true},
+		{"can resume", sub.Can(ActionResume), false},
+		{"known status", SubscriptionStatusExpired.IsKnown(), true},
+		{"unknown status", SubscriptionStatus("frozen").IsKnown(), false},
+		{"known action", ActionMigrate.IsKnown(), true},
+		{"unknown action", SubscriptionAction("refund_last").IsKnown(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+	if got := sub.UnknownStatuses(); len(got) != 1 || got[0] != "frozen" {
+		t.Errorf("UnknownStatuses() = %v", got)
+	}
+	if got := sub.UnknownActions(); len(got) != 1 || got[0] != "refund_last" {
+		t.Errorf("UnknownActions() = %v", got)
+	}
+}
+
+func TestGetMyAssetsLogsUnknownValues(t *testing.T) {
+	logger := &recordingLogger{}
+	c := NewClientWithHTTPClient("org", "secret", &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, `{"status":"success","data":{"subscriptions":[
+			{"subs_id":"s1","status":["active"],"available_actions":["pause"]},
+			{"subs_id":"s2","status":["frozen"],"available_actions":["refund_last"]}
+		]}}`), nil
+	})}, logger)
+
+	res, err := c.GetMyAssets(MyAssetsRequest{ExternalID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Subscriptions) != 2 || !res.Subscriptions[0].HasStatus(SubscriptionStatusActive) {
+		t.Fatalf("subscriptions = %+v", res.Subscriptions)
+	}
+	assertStrings(t, "logs", logger.messages(), []string{
+		"funnelfox_unknown_subscription_status",
+		"funnelfox_unknown_subscription_action",
+	})
+	for _, e := range logger.entries {
+		if e.level != "debug" && e.fields["subs_id"] != "s2" {
+			t.Errorf("%s logged for %v", e.msg, e.fields["subs_id"])
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package funnelfox
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// responseTimeLayouts 响应中时间字段支持的格式，没有时区信息的按 UTC 处理
+var responseTimeLayouts = []string{
+	time.RFC3339Nano,                      // 2006-01-02T15:04:05.999Z / +08:00
+	"2006-01-02T15:04:05.999999999Z0700",  // +0800
+	timeFormat,                            // 2006-01-02T15:04:05.999999
+	"2006-01-02 15:04:05.999999999Z07:00", // 空格分隔
+	"2006-01-02 15:04:05.999999999",
+}
+
+// TimeParseError 时间字段解析失败
+type TimeParseError struct {
+	Field string // 字段路径，如 subscriptions[0].current_period_ends_at
+	Value string // 原始值
+	Err   error
+}
+
+func (e *TimeParseError) Error() string {
+	return fmt.Sprintf("invalid time in %s: %q: %v", e.Field, e.Value, e.Err)
+}
+
+func (e *TimeParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseTime 按 responseTimeLayouts 依次尝试解析，全部失败时返回错误
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range responseTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported time format")
+}
+
+// timeDecoder 解析响应中的时间字段并收集错误
+// 解析失败的字段为 nil，不会得到零值时间
+type timeDecoder struct {
+	errs []error
+}
+
+func (d *timeDecoder) parse(field, s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t, err := parseTime(s)
+	if err != nil {
+		d.errs = append(d.errs, &TimeParseError{Field: field, Value: s, Err: err})
+		return nil
+	}
+	return &t
+}
+
+// SetStrictDecoding 开启后，响应中任何时间字段无法解析都会使请求返回错误；
+// 关闭时（默认）解析失败的字段为 nil 并记录日志
+func (c *Client) SetStrictDecoding(strict bool) {
+	c.strictDecoding = strict
+}
+
+// checkDecode 根据解码模式处理时间解析错误
+func (c *Client) checkDecode(endpoint string, errs []error) *Error {
+	if len(errs) == 0 {
+		return nil
+	}
+	if c.strictDecoding {
+		return WrapError(errors.Join(errs...), "failed to decode response")
+	}
+	for _, err := range errs {
+		c.logger.Error("funnelfox_time_parse_error",
+			String("endpoint", endpoint),
+			ErrorField(err))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package funnelfox
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2024-05-01T10:00:00Z", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), false},
+		{"2024-05-01T18:00:00.5+08:00", time.Date(2024, 5, 1, 10, 0, 0, 5e8, time.UTC), false},
+		{"2024-05-01T18:00:00+0800", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), false},
+		{"2024-05-01T10:00:00.123456", time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC), false},
+		{"2024-05-01 10:00:00Z", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), false},
+		{"2024-05-01 10:00:00", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), false},
+		{"01/05/2024", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseTime(tt.in)
+			if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+				t.Errorf("parseTime(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+const badTimeEvent = `{"event_id":"e1","event_timestamp":"2024-05-01T10:00:00Z","event_type":"order","subtype":"settled",
+	"order":{"order_id":"o1","created_at":"yesterday"}}`
+
+func TestParseEventTimeErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		strict     bool
+		wantErr    bool
+		wantFields []string
+	}{
+		{"valid", testEvent, false, false, nil},
+		{"non-strict nils bad field", badTimeEvent, false, false, []string{"order.created_at"}},
+		{"strict rejects bad field", badTimeEvent, true, true, nil},
+		{"bad event_timestamp", `{"event_id":"e1","event_timestamp":"soon"}`, false, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parse := ParseEvent
+			if tt.strict {
+				parse = ParseEventStrict
+			}
+			event, err := parse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var tpe *TimeParseError
+				if !errors.As(err, &tpe) {
+					t.Errorf("err = %v, want *TimeParseError", err)
+				}
+				return
+			}
+			var fields []string
+			for _, err := range event.TimeErrors() {
+				var tpe *TimeParseError
+				if !errors.As(err, &tpe) {
+					t.Fatalf("TimeErrors() contains %T", err)
+				}
+				fields = append(fields, tpe.Field)
+			}
+			assertStrings(t, "fields", fields, tt.wantFields)
+			if tt.wantFields != nil && event.Order.CreatedAt != nil {
+				t.Error("unparseable created_at should be nil")
+			}
+		})
+	}
+}
+
+func TestClientParseEventLogsTimeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   bool
+		wantErr  bool
+		wantLogs []string
+	}{
+		{"non-strict", false, false, []string{"funnelfox_time_parse_error"}},
+		{"strict", true, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			c := NewClient("org", "secret", logger)
+			c.SetStrictDecoding(tt.strict)
+			_, err := c.ParseEvent([]byte(badTimeEvent))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			assertStrings(t, "logs", logger.messages(), tt.wantLogs)
+		})
+	}
+}
+
+func TestStrictDecodingResponses(t *testing.T) {
+	const assets = `{"status":"success","data":{"subscriptions":[{"subs_id":"s1","started_at":"never"}]}}`
+	tests := []struct {
+		name     string
+		strict   bool
+		wantErr  bool
+		wantLogs []string
+	}{
+		{"non-strict", false, false, []string{"funnelfox_time_parse_error"}},
+		{"strict", true, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			c := NewClientWithHTTPClient("org", "secret", &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return jsonResponse(http.StatusOK, assets), nil
+			})}, logger)
+			c.SetStrictDecoding(tt.strict)
+			res, err := c.GetMyAssets(MyAssetsRequest{ExternalID: "u1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if err == nil && res.Subscriptions[0].StartedAt != nil {
+				t.Error("unparseable started_at should be nil")
+			}
+			assertStrings(t, "logs", logger.messages(), tt.wantLogs)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package funnelfox
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/byte-power/funnelfox"
+
+// span 属性
+const (
+	attrEndpoint       = attribute.Key("funnelfox.endpoint")
+	attrHTTPStatusCode = attribute.Key("http.response.status_code")
+	attrReqID          = attribute.Key("funnelfox.req_id")
+	attrErrorTypes     = attribute.Key("funnelfox.error_types")
+	attrRetryAttempt   = attribute.Key("funnelfox.retry_attempt")
+	attrEventID        = attribute.Key("funnelfox.event_id")
+	attrEventType      = attribute.Key("funnelfox.event_type")
+	attrEventSubtype   = attribute.Key("funnelfox.event_subtype")
+)
+
+// SetTracerProvider 设置 OpenTelemetry TracerProvider，开启请求和 webhook 的 span 记录
+// tp 为 nil 时关闭 tracing
+func (c *Client) SetTracerProvider(tp trace.TracerProvider) {
+	if tp == nil {
+		c.tracer = nil
+		return
+	}
+	c.tracer = tp.Tracer(tracerName)
+}
+
+// SetPropagator 设置向外传递 trace 上下文使用的 propagator，默认使用 otel 全局 propagator
+func (c *Client) SetPropagator(p propagation.TextMapPropagator) {
+	c.propagator = p
+}
+
+func (c *Client) getTracer() trace.Tracer {
+	if c.tracer == nil {
+		return noop.NewTracerProvider().Tracer(tracerName)
+	}
+	return c.tracer
+}
+
+// injectTraceContext 将 trace 上下文写入请求头
+func (c *Client) injectTraceContext(ctx context.Context, header http.Header) {
+	p := c.propagator
+	if p == nil {
+		p = otel.GetTextMapPropagator()
+	}
+	p.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// startRequestSpan 为一次 API 调用创建 span
+func (c *Client) startRequestSpan(ctx context.Context, endpoint string, attempt int) (context.Context, trace.Span) {
+	return c.getTracer().Start(ctx, "funnelfox "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attrEndpoint.String(endpoint),
+			attrRetryAttempt.Int(attempt),
+		))
+}
+
+// annotateResponseSpan 记录响应中的 req_id 和 API 错误类型
+func annotateResponseSpan(span trace.Span, resp *Response) {
+	if resp.ReqID != "" {
+		span.SetAttributes(attrReqID.String(resp.ReqID))
+	}
+	if len(resp.Error) > 0 {
+		types := make([]string, 0, len(resp.Error))
+		for _, e := range resp.Error {
+			types = append(types, e.Type)
+		}
+		span.SetAttributes(attrErrorTypes.StringSlice(types))
+	}
+}
+
+// endSpan 根据结果设置 span 状态并结束 span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// HandleEvent 解析 webhook 事件并交给 handle 处理，解析和处理过程记录在同一个 span 中
+// handle 收到的 ctx 携带该 span，可以为 nil（只解析）
+func (c *Client) HandleEvent(data []byte, handle func(ctx context.Context, event *Event) error) (*Event, error) {
+	ctx, span := c.getTracer().Start(c.context(), "funnelfox webhook",
+		trace.WithSpanKind(trace.SpanKindConsumer))
+
+	event, err := c.ParseEvent(data)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	span.SetAttributes(
+		attrEventID.String(event.EventID),
+		attrEventType.String(string(event.EventType)),
+		attrEventSubtype.String(string(event.Subtype)),
+	)
+
+	if handle != nil {
+		err = handle(ctx, event)
+	}
+	endSpan(span, err)
+	return event, err
+}
@@ -0,0 +1,212 @@
+package funnelfox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newRecordingClient(t *testing.T, rt roundTripFunc) (*Client, *tracetest.SpanRecorder, trace.Tracer) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	c := newTestClient(t, rt)
+	c.SetTracerProvider(tp)
+	c.SetPropagator(propagation.TraceContext{})
+	return c, recorder, tp.Tracer("test")
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestRequestSpan(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus codes.Code
+		wantErrTyp []string
+	}{
+		{
+			name:       "success",
+			status:     http.StatusOK,
+			body:       okResponse,
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "api error",
+			status:     http.StatusBadRequest,
+			body:       `{"status":"error","req_id":"req-1","error":[{"type":"invalid_request","msg":"bad"}]}`,
+			wantStatus: codes.Error,
+			wantErrTyp: []string{"invalid_request"},
+		},
+		{
+			name:       "http error",
+			status:     http.StatusInternalServerError,
+			body:       `{"status":"success","req_id":"req-1"}`,
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder, _ := newRecordingClient(t, func(req *http.Request) (*http.Response, error) {
+				return jsonResponse(tt.status, tt.body), nil
+			})
+			err := c.ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"})
+			if (err != nil) != (tt.wantStatus == codes.Error) {
+				t.Fatalf("err = %v", err)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "funnelfox /subscription/resume" {
+				t.Errorf("name = %q", span.Name())
+			}
+			if span.SpanKind() != trace.SpanKindClient {
+				t.Errorf("kind = %v", span.SpanKind())
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+			if v, ok := spanAttr(span, attrEndpoint); !ok || v.AsString() != "/subscription/resume" {
+				t.Errorf("endpoint = %v", v.Emit())
+			}
+			if v, ok := spanAttr(span, attrHTTPStatusCode); !ok || v.AsInt64() != int64(tt.status) {
+				t.Errorf("status code = %v", v.Emit())
+			}
+			if v, ok := spanAttr(span, attrReqID); !ok || v.AsString() != "req-1" {
+				t.Errorf("req_id = %v", v.Emit())
+			}
+			if tt.wantErrTyp != nil {
+				v, _ := spanAttr(span, attrErrorTypes)
+				if got := v.AsStringSlice(); len(got) != 1 || got[0] != tt.wantErrTyp[0] {
+					t.Errorf("error types = %v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestSpanPropagation(t *testing.T) {
+	var traceparent string
+	c, recorder, tracer := newRecordingClient(t, func(req *http.Request) (*http.Response, error) {
+		traceparent = req.Header.Get("traceparent")
+		return jsonResponse(http.StatusOK, okResponse), nil
+	})
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	if err := c.WithContext(ctx).ResumeSubscription(SubscriptionResumeRequest{ExternalID: "u1", SubsID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	child := spans[0]
+	if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("request span parent = %v, want %v", child.Parent().SpanID(), parent.SpanContext().SpanID())
+	}
+	if child.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("request span not in parent trace")
+	}
+	want := "00-" + child.SpanContext().TraceID().String() + "-" + child.SpanContext().SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+}
+
+func TestHandleEventSpan(t *testing.T) {
+	errHandle := errors.New("handle failed")
+	tests := []struct {
+		name       string
+		data       string
+		handleErr  error
+		wantErr    bool
+		wantStatus codes.Code
+		wantEvent  bool
+	}{
+		{
+			name:       "handled",
+			data:       `{"event_id":"e1","event_timestamp":"2024-05-01T10:00:00Z","event_type":"order","subtype":"settled"}`,
+			wantStatus: codes.Unset,
+			wantEvent:  true,
+		},
+		{
+			name:       "handle error",
+			data:       `{"event_id":"e1","event_timestamp":"2024-05-01T10:00:00Z","event_type":"order","subtype":"settled"}`,
+			handleErr:  errHandle,
+			wantErr:    true,
+			wantStatus: codes.Error,
+			wantEvent:  true,
+		},
+		{
+			name:       "parse error",
+			data:       `{`,
+			wantErr:    true,
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder, tracer := newRecordingClient(t, nil)
+			ctx, parent := tracer.Start(context.Background(), "parent")
+
+			var handleSpan trace.SpanContext
+			_, err := c.WithContext(ctx).HandleEvent([]byte(tt.data), func(ctx context.Context, event *Event) error {
+				handleSpan = trace.SpanContextFromContext(ctx)
+				return tt.handleErr
+			})
+			parent.End()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want 2", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "funnelfox webhook" || span.SpanKind() != trace.SpanKindConsumer {
+				t.Errorf("span = %q %v", span.Name(), span.SpanKind())
+			}
+			if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Errorf("webhook span not a child of ctx span")
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+			if !tt.wantEvent {
+				return
+			}
+			if handleSpan.SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("handle ctx does not carry webhook span")
+			}
+			if v, _ := spanAttr(span, attrEventID); v.AsString() != "e1" {
+				t.Errorf("event_id = %v", v.Emit())
+			}
+			if v, _ := spanAttr(span, attrEventSubtype); v.AsString() != "settled" {
+				t.Errorf("subtype = %v", v.Emit())
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package funnelfox
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 交易报告中的交易状态
+//
+// 取值来自交易报告中观测到的值，API 文档没有给出完整枚举
+const (
+	TransactionStatusSettled  = "settled"
+	TransactionStatusDeclined = "declined"
+	TransactionStatusRefunded = "refunded"
+)
+
+// RefundStatuses IsRefund 视为退款的交易状态（不区分大小写）
+var RefundStatuses = []string{TransactionStatusRefunded}
+
+// RefundPSPTransactionTypes IsRefund 视为退款的 psp_transaction_type 片段（不区分大小写的包含匹配）
+var RefundPSPTransactionTypes = []string{"refund"}
+
+// IsSettled 交易是否成功
+func (t *Transaction) IsSettled() bool {
+	return strings.EqualFold(t.Status, TransactionStatusSettled)
+}
+
+// IsDeclined 交易是否失败
+func (t *Transaction) IsDeclined() bool {
+	return strings.EqualFold(t.Status, TransactionStatusDeclined)
+}
+
+// IsRefund 交易是否为退款
+//
+// API 文档没有定义退款的标识，这里按经验判断：状态属于 RefundStatuses，
+// 或 psp_transaction_type 包含 RefundPSPTransactionTypes 中的片段。
+// 支付渠道的取值不同时，调整这两个变量。
+func (t *Transaction) IsRefund() bool {
+	for _, status := range RefundStatuses {
+		if strings.EqualFold(t.Status, status) {
+			return true
+		}
+	}
+	pspType := strings.ToLower(t.PSPTransactionType)
+	for _, part := range RefundPSPTransactionTypes {
+		if part != "" && strings.Contains(pspType, strings.ToLower(part)) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultTransactionPageSize 未指定 Limit 时每页请求的数量
+const defaultTransactionPageSize = 100
+
+// Transactions 从 req.LastTransactionDate 开始分页遍历交易报告
+//
+// 每页先按 trx_created_at 升序排序，再以页内最大的 trx_created_at 作为下一页的 LastTransactionDate，
+// 边界上重复返回的交易按 TrxID 去重，没有 trx_created_at 的交易同样按 TrxID 去重；
+// 没有 TrxID 的交易无法去重，位于游标时间上时可能重复返回。
+// LastTransactionDate 为零值、请求失败，或整页交易都无法推进游标时，返回错误后结束遍历。
+func (c *Client) Transactions(req TransactionReportRequest) iter.Seq2[Transaction, *Error] {
+	return func(yield func(Transaction, *Error) bool) {
+		if req.LastTransactionDate.IsZero() {
+			// 零值会以空字符串发送，不能作为游标
+			yield(Transaction{}, NewError("LastTransactionDate is required"))
+			return
+		}
+		if req.Limit == nil {
+			limit := defaultTransactionPageSize
+			req.Limit = &limit
+		}
+		// 与游标时间相同的已返回交易，用于去重
+		boundary := make(map[string]bool)
+		// 没有 trx_created_at 的已返回交易，用于去重
+		undated := make(map[string]bool)
+		for {
+			res, err := c.GetTransactionReport(req)
+			if err != nil {
+				yield(Transaction{}, err)
+				return
+			}
+
+			// 不依赖 API 的返回顺序，游标只向后移动
+			sortTransactions(res.Transactions)
+			// 只有推进游标或新增边界交易才算进展，否则下一页会返回相同的数据
+			progressed := false
+			for _, t := range res.Transactions {
+				switch {
+				case t.TrxCreatedAt == nil:
+					if t.TrxID != "" {
+						if undated[t.TrxID] {
+							continue
+						}
+						undated[t.TrxID] = true
+					}
+				case t.TrxCreatedAt.After(req.LastTransactionDate):
+					req.LastTransactionDate = *t.TrxCreatedAt
+					boundary = map[string]bool{}
+					if t.TrxID != "" {
+						boundary[t.TrxID] = true
+					}
+					progressed = true
+				case t.TrxCreatedAt.Equal(req.LastTransactionDate) && t.TrxID != "":
+					if boundary[t.TrxID] {
+						continue
+					}
+					boundary[t.TrxID] = true
+					progressed = true
+				}
+				if !yield(t, nil) {
+					return
+				}
+			}
+
+			if len(res.Transactions) < *req.Limit {
+				return
+			}
+			if !progressed {
+				// 同一时间（或没有时间）的交易超过一页，无法继续翻页
+				yield(Transaction{}, NewError(fmt.Sprintf(
+					"more than %d transactions at %s, increase Limit to continue",
+					*req.Limit, req.LastTransactionDate.Format(time.RFC3339Nano))))
+				return
+			}
+		}
+	}
+}
+
+// sortTransactions 按 (trx_created_at, trx_id) 升序排序，没有时间的交易排在最前
+func sortTransactions(ts []Transaction) {
+	sort.SliceStable(ts, func(i, j int) bool {
+		a, b := ts[i].TrxCreatedAt, ts[j].TrxCreatedAt
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		if !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return ts[i].TrxID < ts[j].TrxID
+	})
+}
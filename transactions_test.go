@@ -0,0 +1,255 @@
+package funnelfox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+var reportBase = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+type reportTrx struct {
+	id     string
+	second int // 小于 0 时没有 trx_created_at
+}
+
+func (trx reportTrx) undated() bool {
+	return trx.second < 0
+}
+
+// reportServer 模拟 /transaction_report：返回 trx_created_at >= last_transaction_date 的前 limit 条交易，
+// 没有时间的交易每页都排在最前返回；页内顺序反转，用于验证迭代器不依赖 API 的返回顺序
+func reportServer(t *testing.T, trxs []reportTrx, cursors *[]time.Time) roundTripFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		var req struct {
+			LastTransactionDate string `json:"last_transaction_date"`
+			Limit               int    `json:"limit"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		last, err := time.Parse(time.RFC3339Nano, req.LastTransactionDate)
+		if err != nil {
+			t.Fatalf("last_transaction_date %q: %v", req.LastTransactionDate, err)
+		}
+		*cursors = append(*cursors, last)
+
+		var page []reportTrx
+		for _, trx := range trxs {
+			if trx.undated() || !reportBase.Add(time.Duration(trx.second)*time.Second).Before(last) {
+				page = append(page, trx)
+			}
+		}
+		sort.SliceStable(page, func(i, j int) bool {
+			if page[i].undated() != page[j].undated() {
+				return page[i].undated()
+			}
+			return page[i].second < page[j].second
+		})
+		page = page[:min(len(page), req.Limit)]
+		slices.Reverse(page)
+
+		items := make([]string, 0, len(page))
+		for _, trx := range page {
+			if trx.undated() {
+				items = append(items, fmt.Sprintf(`{"trx_id":%q,"order_id":"o-%s","status":"settled"}`, trx.id, trx.id))
+				continue
+			}
+			at := reportBase.Add(time.Duration(trx.second) * time.Second)
+			items = append(items, fmt.Sprintf(`{"trx_id":%q,"order_id":"o-%s","status":"settled","trx_created_at":%q}`,
+				trx.id, trx.id, at.Format("2006-01-02T15:04:05")))
+		}
+		return jsonResponse(http.StatusOK,
+			`{"status":"success","data":{"transactions":[`+strings.Join(items, ",")+`]}}`), nil
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	tests := []struct {
+		name        string
+		trxs        []reportTrx
+		limit       int
+		want        []string
+		wantCursors []int
+		wantErr     string
+	}{
+		{
+			name:        "single page",
+			trxs:        []reportTrx{{"t2", 2}, {"t1", 1}},
+			limit:       3,
+			want:        []string{"t1", "t2"},
+			wantCursors: []int{0},
+		},
+		{
+			name:        "pages sorted and checkpointed at max time",
+			trxs:        []reportTrx{{"t1", 1}, {"t2", 2}, {"t3", 3}, {"t4", 4}, {"t5", 5}},
+			limit:       2,
+			want:        []string{"t1", "t2", "t3", "t4", "t5"},
+			wantCursors: []int{0, 2, 3, 4, 5},
+		},
+		{
+			name:        "boundary duplicates skipped",
+			trxs:        []reportTrx{{"t1", 1}, {"t2", 2}, {"t3", 2}, {"t4", 3}, {"t5", 4}},
+			limit:       3,
+			want:        []string{"t1", "t2", "t3", "t4", "t5"},
+			wantCursors: []int{0, 2, 3},
+		},
+		{
+			name:        "same time exceeds page",
+			trxs:        []reportTrx{{"t1", 1}, {"t2", 1}, {"t3", 1}},
+			limit:       2,
+			want:        []string{"t1", "t2"},
+			wantCursors: []int{0, 1},
+			wantErr:     "more than 2 transactions",
+		},
+		{
+			name:        "full page at cursor time",
+			trxs:        []reportTrx{{"t1", 0}, {"t2", 0}},
+			limit:       2,
+			want:        []string{"t1", "t2"},
+			wantCursors: []int{0, 0},
+			wantErr:     "more than 2 transactions",
+		},
+		{
+			name:        "same time without trx id",
+			trxs:        []reportTrx{{"", 1}, {"", 1}},
+			limit:       2,
+			want:        []string{"", "", "", ""},
+			wantCursors: []int{0, 1},
+			wantErr:     "more than 2 transactions",
+		},
+		{
+			name:        "undated rows deduplicated",
+			trxs:        []reportTrx{{"n1", -1}, {"t1", 1}, {"t2", 2}},
+			limit:       3,
+			want:        []string{"n1", "t1", "t2"},
+			wantCursors: []int{0, 2},
+		},
+		{
+			name:        "full page of undated rows",
+			trxs:        []reportTrx{{"n1", -1}, {"n2", -1}},
+			limit:       2,
+			want:        []string{"n2", "n1"},
+			wantCursors: []int{0},
+			wantErr:     "more than 2 transactions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cursors []time.Time
+			c := newTestClient(t, reportServer(t, tt.trxs, &cursors))
+			limit := tt.limit
+
+			var got []string
+			var gotErr *Error
+			for trx, err := range c.Transactions(TransactionReportRequest{LastTransactionDate: reportBase, Limit: &limit}) {
+				if err != nil {
+					gotErr = err
+					break
+				}
+				got = append(got, trx.TrxID)
+			}
+
+			assertStrings(t, "transactions", got, tt.want)
+			var gotCursors []string
+			for _, c := range cursors {
+				gotCursors = append(gotCursors, c.Sub(reportBase).String())
+			}
+			var wantCursors []string
+			for _, s := range tt.wantCursors {
+				wantCursors = append(wantCursors, (time.Duration(s) * time.Second).String())
+			}
+			assertStrings(t, "cursors", gotCursors, wantCursors)
+			if tt.wantErr == "" && gotErr != nil {
+				t.Fatalf("unexpected error: %v", gotErr)
+			}
+			if tt.wantErr != "" && (gotErr == nil || !strings.Contains(gotErr.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want %q", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransactionsRequestError(t *testing.T) {
+	c := newTestClient(t, func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusInternalServerError, `{"status":"error","error":"boom"}`), nil
+	})
+	var errs int
+	for _, err := range c.Transactions(TransactionReportRequest{LastTransactionDate: reportBase}) {
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("yielded %d errors, want 1", errs)
+	}
+}
+
+func TestTransactionsRequiresStartDate(t *testing.T) {
+	c := newTestClient(t, func(r *http.Request) (*http.Response, error) {
+		t.Fatal("unexpected request")
+		return nil, nil
+	})
+	var errs int
+	for _, err := range c.Transactions(TransactionReportRequest{}) {
+		if err == nil || !strings.Contains(err.Error(), "LastTransactionDate") {
+			t.Fatalf("error = %v, want LastTransactionDate error", err)
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("yielded %d errors, want 1", errs)
+	}
+}
+
+func TestTransactionKind(t *testing.T) {
+	tests := []struct {
+		status, pspType           string
+		settled, declined, refund bool
+	}{
+		{status: "settled", settled: true},
+		{status: "SETTLED", settled: true},
+		{status: "declined", declined: true},
+		{status: "refunded", refund: true},
+		{status: "settled", pspType: "REFUND", settled: true, refund: true},
+		{status: "pending"},
+	}
+	for _, tt := range tests {
+		trx := Transaction{Status: tt.status, PSPTransactionType: tt.pspType}
+		if trx.IsSettled() != tt.settled || trx.IsDeclined() != tt.declined || trx.IsRefund() != tt.refund {
+			t.Errorf("%s/%s: settled=%v declined=%v refund=%v", tt.status, tt.pspType,
+				trx.IsSettled(), trx.IsDeclined(), trx.IsRefund())
+		}
+	}
+}
+
+func TestIsRefundConfigurable(t *testing.T) {
+	statuses, types := RefundStatuses, RefundPSPTransactionTypes
+	t.Cleanup(func() { RefundStatuses, RefundPSPTransactionTypes = statuses, types })
+	RefundStatuses = []string{"REVERSED"}
+	RefundPSPTransactionTypes = []string{"Chargeback"}
+
+	tests := []struct {
+		status, pspType string
+		refund          bool
+	}{
+		{status: "reversed", refund: true},
+		{status: "settled", pspType: "CHARGEBACK_DEBIT", refund: true},
+		{status: "refunded"},
+		{status: "settled", pspType: "REFUND"},
+	}
+	for _, tt := range tests {
+		trx := Transaction{Status: tt.status, PSPTransactionType: tt.pspType}
+		if got := trx.IsRefund(); got != tt.refund {
+			t.Errorf("%s/%s: IsRefund() = %v, want %v", tt.status, tt.pspType, got, tt.refund)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package funnelfox
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// UnknownFields SDK 未定义的 JSON 字段，值为原始 JSON，零值表示没有未知字段
+//
+// 没有直接使用 map[string]json.RawMessage：map 字段会使 Transaction、Payment、Currency、Feature
+// 等模型不再可比较（无法使用 == 或作为 map 键），破坏已有调用方。
+// 内部以指针保存 map，模型仍然可以用 == 比较（按引用比较未知字段）；
+// 需要 map 时使用 Map 获取副本。Set 和 Delete 会复制后再修改，不影响模型的其他副本
+type UnknownFields struct {
+	m *map[string]json.RawMessage
+}
+
+func newUnknownFields(m map[string]json.RawMessage) UnknownFields {
+	if len(m) == 0 {
+		return UnknownFields{}
+	}
+	return UnknownFields{m: &m}
+}
+
+func (u UnknownFields) values() map[string]json.RawMessage {
+	if u.m == nil {
+		return nil
+	}
+	return *u.m
+}
+
+// Get 返回字段 key 的原始 JSON
+func (u UnknownFields) Get(key string) (json.RawMessage, bool) {
+	v, ok := u.values()[key]
+	return v, ok
+}
+
+// Len 返回未知字段数量
+func (u UnknownFields) Len() int {
+	return len(u.values())
+}
+
+// Keys 返回所有字段名（已排序）
+func (u UnknownFields) Keys() []string {
+	return slices.Sorted(maps.Keys(u.values()))
+}
+
+// Map 返回所有字段的副本
+func (u UnknownFields) Map() map[string]json.RawMessage {
+	return maps.Clone(u.values())
+}
+
+// Set 设置字段 key，重新编码时按字段名顺序追加到原始字段之后
+func (u *UnknownFields) Set(key string, value json.RawMessage) {
+	m := maps.Clone(u.values())
+	if m == nil {
+		m = make(map[string]json.RawMessage)
+	}
+	m[key] = value
+	*u = newUnknownFields(m)
+}
+
+// Delete 删除字段 key
+func (u *UnknownFields) Delete(key string) {
+	if _, ok := u.Get(key); !ok {
+		return
+	}
+	m := maps.Clone(u.values())
+	delete(m, key)
+	*u = newUnknownFields(m)
+}
+
+// unknownFields 收集响应中 SDK 未定义的字段路径（去重，不含数组下标）
+type unknownFields map[string]bool
+
+func (u unknownFields) add(prefix string, extra UnknownFields) {
+	for k := range extra.values() {
+		u[prefix+k] = true
+	}
+}
+
+func (u unknownFields) addPricePoint(prefix string, pp PricePoint) {
+	u.add(prefix, pp.Extra)
+	u.add(prefix+"currency.", pp.Currency.Extra)
+	for _, f := range pp.Features {
+		u.add(prefix+"features.", f.Extra)
+	}
+}
+
+func (u unknownFields) addSubscription(prefix string, sub *Subscription) {
+	u.add(prefix, sub.Extra)
+	u.addPricePoint(prefix+"price_point.", sub.PricePoint)
+}
+
+func (u unknownFields) addOneoff(prefix string, oneoff *OneOffPurchase) {
+	u.add(prefix, oneoff.Extra)
+	u.addPricePoint(prefix+"price_point.", oneoff.PricePoint)
+}
+
+func (u unknownFields) addEvent(e *Event) {
+	u.add("", e.Extra)
+	if e.Subscription != nil {
+		u.addSubscription("subscription.", e.Subscription)
+	}
+	if e.Order != nil {
+		u.add("order.", e.Order.Extra)
+	}
+	if e.Oneoff != nil {
+		u.addOneoff("oneoff.", e.Oneoff)
+	}
+}
+
+// logUnknownFields 记录 source（API 路径或 webhook）中出现的未知字段，便于及时补充模型
+func (c *Client) logUnknownFields(source string, u unknownFields) {
+	if len(u) == 0 {
+		return
+	}
+	keys := slices.Sorted(maps.Keys(u))
+	c.logger.Info("funnelfox_unknown_fields",
+		String("source", source),
+		String("fields", strings.Join(keys, ",")))
+}
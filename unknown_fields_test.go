@@ -0,0 +1,122 @@
+package funnelfox
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestLogUnknownFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		call       func(c *Client) *Error
+		wantSource string
+		wantFields string
+	}{
+		{
+			name: "price points",
+			body: `{"status":"success","data":{"price_points":[
+				{"ident":"a","currency":{"code":"USD","iso":840},"features":[{"ident":"pro","kind":"x"}],"region":"US"},
+				{"ident":"b","region":"EU"}]}}`,
+			call: func(c *Client) *Error {
+				_, err := c.ListPricePoints(PricePointsListRequest{})
+				return err
+			},
+			wantSource: "/price_points",
+			wantFields: "price_points.currency.iso,price_points.features.kind,price_points.region",
+		},
+		{
+			name: "my assets",
+			body: `{"status":"success","data":{"subscriptions":[{"subs_id":"s1","cohort":"a","price_point":{"ident":"a","region":"US"}}],
+				"oneoffs":[{"oneoff_id":"o1","source":"web"}]}}`,
+			call: func(c *Client) *Error {
+				_, err := c.GetMyAssets(MyAssetsRequest{ExternalID: "u1"})
+				return err
+			},
+			wantSource: "/my_assets",
+			wantFields: "oneoffs.source,subscriptions.cohort,subscriptions.price_point.region",
+		},
+		{
+			name: "payments history",
+			body: `{"status":"success","data":{"payments":[{"order_id":"o1","method":"card","currency":{"code":"USD","iso":840}}]}}`,
+			call: func(c *Client) *Error {
+				_, err := c.GetPaymentsHistory(PaymentsHistoryRequest{ExternalID: "u1"})
+				return err
+			},
+			wantSource: "/payments_history",
+			wantFields: "payments.currency.iso,payments.method",
+		},
+		{
+			name: "transaction report",
+			body: `{"status":"success","data":{"transactions":[{"trx_id":"t1","risk_score":10}]}}`,
+			call: func(c *Client) *Error {
+				_, err := c.GetTransactionReport(TransactionReportRequest{})
+				return err
+			},
+			wantSource: "/transaction_report",
+			wantFields: "transactions.risk_score",
+		},
+		{
+			name: "no unknown fields",
+			body: `{"status":"success","data":{"transactions":[{"trx_id":"t1"}]}}`,
+			call: func(c *Client) *Error {
+				_, err := c.GetTransactionReport(TransactionReportRequest{})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			c := NewClientWithHTTPClient("org", "secret", &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return jsonResponse(http.StatusOK, tt.body), nil
+			})}, logger)
+			if err := tt.call(c); err != nil {
+				t.Fatal(err)
+			}
+			var got []logEntry
+			for _, e := range logger.entries {
+				if e.msg == "funnelfox_unknown_fields" {
+					got = append(got, e)
+				}
+			}
+			if tt.wantFields == "" {
+				if len(got) != 0 {
+					t.Errorf("unexpected log %v", got[0].fields)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d unknown field logs, want 1", len(got))
+			}
+			if got[0].fields["source"] != tt.wantSource || got[0].fields["fields"] != tt.wantFields {
+				t.Errorf("log fields = %v, want source %s fields %s", got[0].fields, tt.wantSource, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestUnknownFieldsPreserved(t *testing.T) {
+	var res TransactionReportResponse
+	data := `{"transactions":[{"trx_id":"t1","risk_score":10,"tags":["a"]}]}`
+	if err := json.Unmarshal([]byte(data), &res); err != nil {
+		t.Fatal(err)
+	}
+	extra := res.Transactions[0].Extra
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"risk_score", `10`},
+		{"tags", `["a"]`},
+	}
+	for _, tt := range tests {
+		if v, ok := extra.Get(tt.key); !ok || string(v) != tt.want {
+			t.Errorf("Extra[%s] = %s, %v; want %s", tt.key, v, ok, tt.want)
+		}
+	}
+	if _, ok := extra.Get("trx_id"); ok {
+		t.Error("known field kept as unknown")
+	}
+}
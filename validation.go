@@ -0,0 +1,209 @@
+package funnelfox
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // JSON 字段名
+	Message string `json:"message"` // 错误说明
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError 请求校验错误，包含所有不合法的字段
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// validator 发送前可以自校验的请求
+type validator interface {
+	Validate() error
+}
+
+// validateRequest 校验请求，不合法时返回包装后的 *Error
+func validateRequest(req validator) *Error {
+	if err := req.Validate(); err != nil {
+		return WrapError(err, "invalid request")
+	}
+	return nil
+}
+
+// fieldErrors 收集字段错误
+type fieldErrors []FieldError
+
+func (fe *fieldErrors) add(field, format string, args ...any) {
+	*fe = append(*fe, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (fe fieldErrors) err() error {
+	if len(fe) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fe}
+}
+
+func isValidPeriodDurationUnit(u PeriodDurationUnit) bool {
+	switch u {
+	case PeriodDurationUnitMinutes, PeriodDurationUnitDays, PeriodDurationUnitWeeks,
+		PeriodDurationUnitMonths, PeriodDurationUnitYears:
+		return true
+	}
+	return false
+}
+
+// checkPeriod 校验周期长度和单位
+func (fe *fieldErrors) checkPeriod(periodField string, period int, unitField string, unit PeriodDurationUnit) {
+	if period <= 0 {
+		fe.add(periodField, "must be positive")
+	}
+	if !isValidPeriodDurationUnit(unit) {
+		fe.add(unitField, "invalid period duration unit %q", unit)
+	}
+}
+
+// Validate 校验价格点创建请求中字段组合是否合法
+func (r PricePointCreateRequest) Validate() error {
+	var fe fieldErrors
+	if r.Ident == "" {
+		fe.add("ident", "is required")
+	}
+	if len(r.CurrencyCode) != 3 {
+		fe.add("currency_code", "must be a 3-letter ISO 4217 code")
+	}
+	for _, p := range []struct {
+		field string
+		price float64
+	}{
+		{"lifetime_price", r.LifetimePrice},
+		{"intro_paid_trial_price", r.IntroPaidTrialPrice},
+		{"next_price", r.NextPrice},
+	} {
+		if p.price < 0 {
+			fe.add(p.field, "must not be negative")
+		}
+	}
+
+	hasFreeTrial := r.IntroFreeTrialPeriod != 0 || r.IntroFreeTrialPeriodDuration != ""
+	hasPaidTrial := r.IntroPaidTrialPrice != 0 || r.IntroPaidTrialPeriod != 0 || r.IntroPaidTrialPeriodDuration != ""
+	hasNext := r.NextPrice != 0 || r.NextPeriod != 0 || r.NextPeriodDuration != ""
+
+	switch r.IntroType {
+	case "", IntroTypeNoIntro:
+		if hasFreeTrial {
+			fe.add("intro_free_trial_period", "must be empty when intro_type is %s", IntroTypeNoIntro)
+		}
+		if hasPaidTrial {
+			fe.add("intro_paid_trial_period", "must be empty when intro_type is %s", IntroTypeNoIntro)
+		}
+	case IntroTypeFreeTrial:
+		fe.checkPeriod("intro_free_trial_period", r.IntroFreeTrialPeriod,
+			"intro_free_trial_period_duration", r.IntroFreeTrialPeriodDuration)
+		if hasPaidTrial {
+			fe.add("intro_paid_trial_period", "must be empty when intro_type is %s", IntroTypeFreeTrial)
+		}
+	case IntroTypePaidTrial:
+		if r.IntroPaidTrialPrice <= 0 {
+			fe.add("intro_paid_trial_price", "must be positive when intro_type is %s", IntroTypePaidTrial)
+		}
+		fe.checkPeriod("intro_paid_trial_period", r.IntroPaidTrialPeriod,
+			"intro_paid_trial_period_duration", r.IntroPaidTrialPeriodDuration)
+		if hasFreeTrial {
+			fe.add("intro_free_trial_period", "must be empty when intro_type is %s", IntroTypePaidTrial)
+		}
+	default:
+		fe.add("intro_type", "unknown intro type %q", r.IntroType)
+	}
+
+	if r.LifetimePrice > 0 {
+		// 终身价格点：一次性付费，没有续费周期和试用
+		if hasNext {
+			fe.add("next_period", "must be empty for a lifetime price point")
+		}
+		if r.IntroType != "" && r.IntroType != IntroTypeNoIntro {
+			fe.add("intro_type", "must be %s for a lifetime price point", IntroTypeNoIntro)
+		}
+	} else {
+		if r.NextPrice <= 0 {
+			fe.add("next_price", "must be positive for a recurring price point")
+		}
+		fe.checkPeriod("next_period", r.NextPeriod, "next_period_duration", r.NextPeriodDuration)
+	}
+	return fe.err()
+}
+
+// Validate 校验 feature 创建请求
+func (r FeatureCreateRequest) Validate() error {
+	var fe fieldErrors
+	if r.Ident == "" {
+		fe.add("ident", "is required")
+	}
+	switch r.FeatureType {
+	case FeatureTypeTimebased, FeatureTypeLifetime, FeatureTypeConsumable:
+	default:
+		fe.add("feature_type", "unknown feature type %q", r.FeatureType)
+	}
+	return fe.err()
+}
+
+// Validate 校验折扣请求
+func (r DiscountRequest) Validate() error {
+	var fe fieldErrors
+	fe.checkSubscription(r.ExternalID, r.SubsID)
+	if r.Percent < 1 || r.Percent > 100 {
+		fe.add("percent", "must be between 1 and 100")
+	}
+	if r.CountOfIterations != nil && *r.CountOfIterations <= 0 {
+		fe.add("count_of_iterations", "must be positive")
+	}
+	return fe.err()
+}
+
+// Validate 校验延迟订阅请求
+func (r SubscriptionDeferRequest) Validate() error {
+	var fe fieldErrors
+	fe.checkSubscription(r.ExternalID, r.SubsID)
+	fe.checkFutureTime("defer_till", r.DeferTill)
+	return fe.err()
+}
+
+// Validate 校验暂停订阅请求
+func (r SubscriptionPauseRequest) Validate() error {
+	var fe fieldErrors
+	fe.checkSubscription(r.ExternalID, r.SubsID)
+	fe.checkFutureTime("pause_till", r.PauseTill)
+	return fe.err()
+}
+
+func (fe *fieldErrors) checkSubscription(externalID, subsID string) {
+	if externalID == "" {
+		fe.add("external_id", "is required")
+	}
+	if subsID == "" {
+		fe.add("subs_id", "is required")
+	}
+}
+
+// checkFutureTime 校验时间已设置且晚于当前时间
+func (fe *fieldErrors) checkFutureTime(field string, t time.Time) {
+	if t.IsZero() {
+		fe.add(field, "is required")
+		return
+	}
+	if !t.After(time.Now()) {
+		fe.add(field, "must be in the future")
+	}
+}
@@ -0,0 +1,115 @@
+package funnelfox
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	zero := 0
+	monthly := PricePointCreateRequest{
+		Ident:              "monthly",
+		CurrencyCode:       "USD",
+		NextPrice:          9.99,
+		NextPeriod:         1,
+		NextPeriodDuration: PeriodDurationUnitMonths,
+	}
+	with := func(f func(r *PricePointCreateRequest)) PricePointCreateRequest {
+		r := monthly
+		f(&r)
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		req        validator
+		wantFields []string
+	}{
+		{"recurring", monthly, nil},
+		{"lifetime", PricePointCreateRequest{Ident: "lt", CurrencyCode: "USD", LifetimePrice: 99}, nil},
+		{"free trial", with(func(r *PricePointCreateRequest) {
+			r.IntroType = IntroTypeFreeTrial
+			r.IntroFreeTrialPeriod = 7
+			r.IntroFreeTrialPeriodDuration = PeriodDurationUnitDays
+		}), nil},
+		{"paid trial", with(func(r *PricePointCreateRequest) {
+			r.IntroType = IntroTypePaidTrial
+			r.IntroPaidTrialPrice = 0.99
+			r.IntroPaidTrialPeriod = 1
+			r.IntroPaidTrialPeriodDuration = PeriodDurationUnitWeeks
+		}), nil},
+		{"missing ident and currency", with(func(r *PricePointCreateRequest) {
+			r.Ident = ""
+			r.CurrencyCode = "US"
+		}), []string{"ident", "currency_code"}},
+		{"negative price", with(func(r *PricePointCreateRequest) { r.NextPrice = -1 }), []string{"next_price", "next_price"}},
+		{"free trial without unit", with(func(r *PricePointCreateRequest) {
+			r.IntroType = IntroTypeFreeTrial
+			r.IntroFreeTrialPeriod = 7
+		}), []string{"intro_free_trial_period_duration"}},
+		{"trial fields without intro", with(func(r *PricePointCreateRequest) {
+			r.IntroFreeTrialPeriod = 7
+		}), []string{"intro_free_trial_period"}},
+		{"paid trial without price", with(func(r *PricePointCreateRequest) {
+			r.IntroType = IntroTypePaidTrial
+			r.IntroPaidTrialPeriod = 1
+			r.IntroPaidTrialPeriodDuration = PeriodDurationUnitWeeks
+		}), []string{"intro_paid_trial_price"}},
+		{"unknown intro type", with(func(r *PricePointCreateRequest) { r.IntroType = "bogus" }), []string{"intro_type"}},
+		{"lifetime with period", PricePointCreateRequest{
+			Ident: "lt", CurrencyCode: "USD", LifetimePrice: 99, NextPeriod: 1,
+		}, []string{"next_period"}},
+		{"recurring invalid unit", with(func(r *PricePointCreateRequest) {
+			r.NextPeriod = 0
+			r.NextPeriodDuration = "fortnights"
+		}), []string{"next_period", "next_period_duration"}},
+		{"feature", FeatureCreateRequest{Ident: "premium", FeatureType: FeatureTypeTimebased}, nil},
+		{"feature invalid", FeatureCreateRequest{FeatureType: "bogus"}, []string{"ident", "feature_type"}},
+		{"discount", DiscountRequest{ExternalID: "u1", SubsID: "s1", Percent: 50}, nil},
+		{"discount invalid", DiscountRequest{Percent: 101, CountOfIterations: &zero}, []string{"external_id", "subs_id", "percent", "count_of_iterations"}},
+		{"defer", SubscriptionDeferRequest{ExternalID: "u1", SubsID: "s1", DeferTill: future}, nil},
+		{"defer in past", SubscriptionDeferRequest{ExternalID: "u1", SubsID: "s1", DeferTill: past}, []string{"defer_till"}},
+		{"pause", SubscriptionPauseRequest{ExternalID: "u1", SubsID: "s1", PauseTill: future}, nil},
+		{"pause without time", SubscriptionPauseRequest{ExternalID: "u1", SubsID: "s1"}, []string{"pause_till"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("err = %v, want *ValidationError", err)
+			}
+			fields := make([]string, 0, len(ve.Fields))
+			for _, f := range ve.Fields {
+				fields = append(fields, f.Field)
+			}
+			assertStrings(t, "fields", fields, tt.wantFields)
+		})
+	}
+}
+
+func TestValidationBeforeSend(t *testing.T) {
+	c := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		t.Error("invalid request should not be sent")
+		return jsonResponse(http.StatusOK, okResponse), nil
+	})
+	err := c.ApplyDiscount(DiscountRequest{ExternalID: "u1", SubsID: "s1", Percent: 0})
+	if err == nil || !strings.Contains(err.Error(), "percent: must be between 1 and 100") {
+		t.Fatalf("err = %v", err)
+	}
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Errorf("err does not wrap *ValidationError")
+	}
+}